@@ -0,0 +1,316 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/handlers"
+)
+
+// benchKinds are the kinds of requests of a benchmark, in report order.
+var benchKinds = []string{"list", "detail", "format"}
+
+// benchPageSize is the default page size of the listings, used to request
+// every page of them.
+const benchPageSize = 10
+
+// benchResult is the outcome of one benchmark request.
+type benchResult struct {
+	kind     string
+	duration time.Duration
+	// failure is the status or error of a failed request, empty on success.
+	failure string
+}
+
+// benchCommand replays a mix of listing, detail and format requests against a
+// running instance, or against the API served in-process, and reports the
+// latency percentiles of each kind of request, for capacity planning.
+func benchCommand(a *app, args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	target := flags.String("url", "", "base URL of a running instance (default: serve the API in-process)")
+	requests := flags.Int("n", 1000, "number of requests")
+	concurrency := flags.Int("c", 10, "number of concurrent requests")
+	mix := flags.String("mix", "list=5,detail=4,format=1", "relative weights of the list, detail and format requests")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of each request")
+	flags.Parse(args)
+	weights, err := parseBenchMix(*mix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -mix %q: %v\n", *mix, err)
+		return 2
+	}
+	if *requests < 1 || *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "-n and -c must be positive")
+		return 2
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	baseURL := strings.TrimSuffix(*target, "/")
+	if baseURL == "" {
+		gin.SetMode(a.cfg.Server.GinMode)
+		setupCatalog(a)
+		defer a.catalog.StopBackground(context.Background())
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			log.Printf("Error listening for the in-process server: %v", err)
+			return 1
+		}
+		srv := &http.Server{Handler: newHandler(a)}
+		go srv.Serve(lis)
+		defer srv.Close()
+		baseURL = "http://" + lis.Addr().String()
+	}
+	client := &http.Client{
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+		Timeout:   *timeout,
+	}
+
+	ids, err := benchDatasetIDs(ctx, client, baseURL)
+	if err != nil {
+		log.Printf("Error listing the datasets: %v", err)
+		return 1
+	}
+	paths := benchPaths(a.server.Routes, ids)
+	for kind, weight := range weights {
+		if weight > 0 && len(paths[kind]) == 0 {
+			log.Printf("No %s requests to send, leaving them out", kind)
+			weights[kind] = 0
+		}
+	}
+	log.Printf("Sending %d requests to %s, %d at a time, for %d datasets", *requests, baseURL, *concurrency, len(ids))
+	started := time.Now()
+	results := runBench(ctx, client, baseURL, weights, paths, *requests, *concurrency)
+	elapsed := time.Since(started)
+
+	failed := reportBench(os.Stdout, results, elapsed)
+	if ctx.Err() != nil {
+		log.Printf("Interrupted after %d requests", len(results))
+		return 1
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseBenchMix parses the weights of the request kinds, given as
+// kind=weight pairs separated by commas. Kinds left out have weight 0.
+func parseBenchMix(mix string) (map[string]int, error) {
+	weights := make(map[string]int)
+	total := 0
+	for _, pair := range strings.Split(mix, ",") {
+		kind, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if !slices.Contains(benchKinds, kind) {
+			return nil, fmt.Errorf("unknown request kind %q, expected one of %s", kind, strings.Join(benchKinds, ", "))
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative integer", kind)
+		}
+		weights[kind] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no request kind has a weight")
+	}
+	return weights, nil
+}
+
+// benchDatasetIDs returns the IDs of the datasets of the catalog at baseURL,
+// read from its NDJSON export.
+func benchDatasetIDs(ctx context.Context, client *http.Client, baseURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/export/ndjson", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/export/ndjson answered %s", resp.Status)
+	}
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var ds struct{ Id string }
+		if err := json.Unmarshal(scanner.Bytes(), &ds); err != nil {
+			return nil, err
+		}
+		ids = append(ids, ds.Id)
+	}
+	return ids, scanner.Err()
+}
+
+// benchPaths returns the request URIs of routes for each kind of request:
+// list, every page of the listings refreshed after each sync; detail, the
+// document of every dataset in each cached profile (the /go shortlinks, which
+// count clicks, are left out); format, every format of the listings offering
+// several, except the dumps.
+func benchPaths(routes []handlers.Route, ids []string) map[string][]string {
+	pages := max((len(ids)+benchPageSize-1)/benchPageSize, 1)
+	paths := make(map[string][]string)
+	for _, r := range routes {
+		switch {
+		case r.Prerender:
+			// The JSON:API collection pages with page[number] instead of page.
+			param := "page"
+			if strings.HasPrefix(r.Path, "/jsonapi/") {
+				param = "page[number]"
+			}
+			paths["list"] = append(paths["list"], r.Path)
+			for page := 2; page <= pages; page++ {
+				paths["list"] = append(paths["list"], fmt.Sprintf("%s?%s=%d", r.Path, param, page))
+			}
+		case strings.HasSuffix(r.Path, "/:uuid") && r.CacheResponse:
+			for _, id := range ids {
+				paths["detail"] = append(paths["detail"], strings.TrimSuffix(r.Path, ":uuid")+id)
+			}
+		}
+		if len(r.Formats) > 1 && !strings.Contains(r.Path, ":") && !strings.HasSuffix(r.Path, "/dump") {
+			for _, format := range r.Formats {
+				paths["format"] = append(paths["format"], r.Path+"?format="+format)
+			}
+		}
+	}
+	return paths
+}
+
+// runBench sends requests requests, concurrency at a time, each of a kind
+// drawn by weight and for a random URI of that kind, and returns their
+// results. It stops early when ctx is cancelled.
+func runBench(ctx context.Context, client *http.Client, baseURL string, weights map[string]int, paths map[string][]string, requests, concurrency int) []benchResult {
+	var draw []string
+	for _, kind := range benchKinds {
+		for range weights[kind] {
+			draw = append(draw, kind)
+		}
+	}
+	results := make([]benchResult, requests)
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(sent.Add(1)) - 1
+				if i >= requests {
+					return
+				}
+				kind := draw[rand.IntN(len(draw))]
+				uri := paths[kind][rand.IntN(len(paths[kind]))]
+				results[i] = benchRequest(ctx, client, baseURL, uri)
+				results[i].kind = kind
+			}
+		}()
+	}
+	wg.Wait()
+	done := min(int(sent.Load()), requests)
+	return slices.DeleteFunc(results[:done], func(r benchResult) bool { return r.kind == "" })
+}
+
+// benchRequest sends a GET request for uri and reads the whole response.
+func benchRequest(ctx context.Context, client *http.Client, baseURL, uri string) benchResult {
+	started := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+uri, nil)
+	if err != nil {
+		return benchResult{failure: err.Error()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return benchResult{duration: time.Since(started), failure: err.Error()}
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	result := benchResult{duration: time.Since(started)}
+	switch {
+	case err != nil:
+		result.failure = err.Error()
+	case resp.StatusCode >= http.StatusBadRequest:
+		result.failure = resp.Status + " " + uri
+	}
+	return result
+}
+
+// reportBench writes the number of requests, failures and latency
+// percentiles of each kind of request and of all of them, followed by the
+// failures, and returns the number of failed requests.
+func reportBench(w io.Writer, results []benchResult, elapsed time.Duration) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "kind\trequests\tfailed\tp50\tp95\tp99\tmax\t")
+	row := func(kind string, results []benchResult) {
+		durations := make([]time.Duration, len(results))
+		failed := 0
+		for i, r := range results {
+			durations[i] = r.duration
+			if r.failure != "" {
+				failed++
+			}
+		}
+		slices.Sort(durations)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t\n", kind, len(results), failed,
+			percentile(durations, 0.50), percentile(durations, 0.95), percentile(durations, 0.99), percentile(durations, 1))
+	}
+	for _, kind := range benchKinds {
+		var ofKind []benchResult
+		for _, r := range results {
+			if r.kind == kind {
+				ofKind = append(ofKind, r)
+			}
+		}
+		if len(ofKind) > 0 {
+			row(kind, ofKind)
+		}
+	}
+	row("all", results)
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d requests in %s, %.1f requests/s\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds())
+
+	failures := make(map[string]int)
+	for _, r := range results {
+		if r.failure != "" {
+			failures[r.failure]++
+		}
+	}
+	failed := 0
+	for _, failure := range slices.Sorted(maps.Keys(failures)) {
+		fmt.Fprintf(w, "%d failed: %s\n", failures[failure], failure)
+		failed += failures[failure]
+	}
+	return failed
+}
+
+// percentile returns the p-th percentile of the sorted durations, by the
+// nearest-rank method, in milliseconds.
+func percentile(sorted []time.Duration, p float64) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	rank := max(int(math.Ceil(p*float64(len(sorted))))-1, 0)
+	return fmt.Sprintf("%.1fms", float64(sorted[rank])/float64(time.Millisecond))
+}
@@ -0,0 +1,351 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// budgetLowWater is the share of CACHE_MEMORY_BUDGET the caches are evicted
+// down to once they exceed it, so eviction does not run on every insert.
+const budgetLowWater = 0.9
+
+// budgetedCache is a cache held against CACHE_MEMORY_BUDGET.
+type budgetedCache struct {
+	name string
+	// bytes returns the estimated size of the entries.
+	bytes func() int64
+	// evict removes the entries expiring first until at least want bytes are
+	// freed, and returns the bytes freed and the number of entries removed.
+	evict func(want int64) (int64, int)
+}
+
+// newBudgetedCaches returns the caches held against CACHE_MEMORY_BUDGET in
+// eviction order: memoized documents are the cheapest to rebuild, from the
+// cached pages and details, and pages the most expensive, from the upstream
+// API. Last-known-good pages share their datasets with the cached page of the
+// same key, so only those no longer cached are counted, and evicted before the
+// cached pages; evicting a page drops its last-known-good copy along with it.
+// The catalog index is not counted, as it references the datasets of the
+// cached pages; it is dropped when pages are evicted instead. Caches added by
+// RegisterCache are evicted first.
+func (c *Client) newBudgetedCaches() []budgetedCache {
+	return []budgetedCache{
+		{"documents", c.documentBytes, c.evictDocuments},
+		{"notFound", c.notFoundBytes, c.evictNotFound},
+		{"openapi", c.openAPIBytes, c.evictOpenAPI},
+		{"details", c.detailBytes, c.evictDetails},
+		{"mobility", c.mobilityBytes, c.evictMobility},
+		{"lastGood", c.lastGoodBytes, c.evictLastGood},
+		{"pages", c.pageBytes, c.evictPages},
+	}
+}
+
+// budgetGauge reports the memory budget of the caches.
+func (c *Client) budgetGauge() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "catalog_cache_memory_budget_bytes",
+		Help: "Memory budget of the caches (CACHE_MEMORY_BUDGET), 0 when unlimited.",
+	}, func() float64 { return float64(c.memoryBudget()) })
+}
+
+// memoryBudget returns the number of bytes the caches may hold together,
+// CACHE_MEMORY_BUDGET, or 0 for no limit.
+func (c *Client) memoryBudget() int64 {
+	return c.cfg.Cache.MemoryBudget
+}
+
+// cacheBytes returns the estimated size of the entries of cache and whether it
+// is held against the budget.
+func (c *Client) cacheBytes(cache string) (int64, bool) {
+	for _, bc := range c.budgetedCaches {
+		if bc.name == cache {
+			return bc.bytes(), true
+		}
+	}
+	return 0, false
+}
+
+// EnforceCacheBudget evicts entries once the caches together exceed the
+// memory budget, until they are down to budgetLowWater of it. Caches call it
+// after inserting entries, without holding their locks.
+func (c *Client) EnforceCacheBudget() {
+	budget := c.memoryBudget()
+	if budget == 0 {
+		return
+	}
+	c.budgetMutex.Lock()
+	defer c.budgetMutex.Unlock()
+	sizes := make([]int64, len(c.budgetedCaches))
+	var total int64
+	for i, bc := range c.budgetedCaches {
+		sizes[i] = bc.bytes()
+		total += sizes[i]
+	}
+	if total <= budget {
+		return
+	}
+	excess := total - int64(float64(budget)*budgetLowWater)
+	for i, bc := range c.budgetedCaches {
+		if excess <= 0 {
+			break
+		}
+		if sizes[i] == 0 {
+			continue
+		}
+		freed, n := bc.evict(excess)
+		c.CountEvictions(bc.name, n)
+		excess -= freed
+	}
+}
+
+// EvictEarliest deletes from m the entries expiring first until at least want
+// bytes are freed, and returns the bytes freed and the number of entries
+// deleted. The caller must hold the lock of m.
+func EvictEarliest[K comparable, V any](m map[K]V, entry func(V) (size int, expiration time.Time), want int64) (int64, int) {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		_, ea := entry(m[a])
+		_, eb := entry(m[b])
+		return ea.Compare(eb)
+	})
+	var freed int64
+	n := 0
+	for _, key := range keys {
+		if freed >= want {
+			break
+		}
+		size, _ := entry(m[key])
+		delete(m, key)
+		freed += int64(size)
+		n++
+	}
+	return freed, n
+}
+
+// encodedSize estimates the memory held by v, such as a dataset, a slice of
+// them or a document, by the length of its JSON encoding.
+func encodedSize(v any) int {
+	var n countingWriter
+	if err := json.NewEncoder(&n).Encode(v); err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter int
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	*w += countingWriter(len(b))
+	return len(b), nil
+}
+
+func detailEntry(item detailItem) (int, time.Time) { return item.size, item.expiration }
+
+func pageEntry(item cacheItem) (int, time.Time) { return item.size, item.expiration }
+
+func documentEntry(item documentItem) (int, time.Time) { return item.size, item.expiration }
+
+func openAPIEntry(item openAPICacheItem) (int, time.Time) { return item.size, item.expiration }
+
+func lastGoodEntry(item lastGoodItem) (int, time.Time) { return item.size, item.fetched }
+
+// notFoundEntrySize is the estimated size of an entry of notFoundCache: a
+// dataset UUID, its expiration and the overhead of the map.
+const notFoundEntrySize = 64
+
+func notFoundEntry(expiration time.Time) (int, time.Time) { return notFoundEntrySize, expiration }
+
+func (c *Client) documentBytes() int64 {
+	c.documentMutex.RLock()
+	defer c.documentMutex.RUnlock()
+	var total int64
+	for _, item := range c.documentCache {
+		total += int64(item.size)
+	}
+	return total
+}
+
+func (c *Client) evictDocuments(want int64) (int64, int) {
+	c.documentMutex.Lock()
+	defer c.documentMutex.Unlock()
+	return EvictEarliest(c.documentCache, documentEntry, want)
+}
+
+func (c *Client) notFoundBytes() int64 {
+	c.notFoundMutex.RLock()
+	defer c.notFoundMutex.RUnlock()
+	return int64(len(c.notFoundCache) * notFoundEntrySize)
+}
+
+func (c *Client) evictNotFound(want int64) (int64, int) {
+	c.notFoundMutex.Lock()
+	defer c.notFoundMutex.Unlock()
+	return EvictEarliest(c.notFoundCache, notFoundEntry, want)
+}
+
+func (c *Client) openAPIBytes() int64 {
+	c.openAPICacheMutex.RLock()
+	defer c.openAPICacheMutex.RUnlock()
+	var total int64
+	for _, item := range c.openAPICache {
+		total += int64(item.size)
+	}
+	return total
+}
+
+func (c *Client) evictOpenAPI(want int64) (int64, int) {
+	c.openAPICacheMutex.Lock()
+	defer c.openAPICacheMutex.Unlock()
+	return EvictEarliest(c.openAPICache, openAPIEntry, want)
+}
+
+func (c *Client) detailBytes() int64 {
+	c.detailMutex.RLock()
+	defer c.detailMutex.RUnlock()
+	var total int64
+	for _, item := range c.detailCache {
+		total += int64(item.size)
+	}
+	return total
+}
+
+func (c *Client) evictDetails(want int64) (int64, int) {
+	c.detailMutex.Lock()
+	defer c.detailMutex.Unlock()
+	return EvictEarliest(c.detailCache, detailEntry, want)
+}
+
+// pageBytes returns the size of the pages held in memory; pages in memcached
+// are not held against the budget.
+func (c *Client) pageBytes() int64 {
+	m, ok := c.datasetCache.(*memoryPageCache)
+	if !ok {
+		return 0
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var total int64
+	for _, item := range m.items {
+		total += int64(item.size)
+	}
+	return total
+}
+
+func (c *Client) evictPages(want int64) (int64, int) {
+	m, ok := c.datasetCache.(*memoryPageCache)
+	if !ok {
+		return 0, 0
+	}
+	m.mu.Lock()
+	held := maps.Clone(m.items)
+	freed, n := EvictEarliest(m.items, pageEntry, want)
+	for key := range m.items {
+		delete(held, key)
+	}
+	m.mu.Unlock()
+	// The last-known-good copies and the catalog index would keep the
+	// datasets of the evicted pages in memory.
+	c.lastGoodMutex.Lock()
+	dropped := 0
+	for key, item := range held {
+		if good, found := c.lastGood[key]; found && sharesItems(good.data.Items, item.data) {
+			delete(c.lastGood, key)
+			dropped++
+		}
+	}
+	c.lastGoodMutex.Unlock()
+	c.CountEvictions("lastGood", dropped)
+	if n > 0 && c.currentCatalogIndex.Swap(nil) != nil {
+		c.CountEvictions("index", 1)
+	}
+	return freed, n
+}
+
+// cachedPageItems returns the datasets of the pages held in memory by key.
+func (c *Client) cachedPageItems() map[pageKey][]transformers.Dataset {
+	m, ok := c.datasetCache.(*memoryPageCache)
+	if !ok {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make(map[pageKey][]transformers.Dataset, len(m.items))
+	for key, item := range m.items {
+		items[key] = item.data
+	}
+	return items
+}
+
+// sharesItems reports whether a and b are slices of the same datasets.
+func sharesItems(a, b []transformers.Dataset) bool {
+	return len(a) > 0 && len(b) > 0 && &a[0] == &b[0]
+}
+
+// lastGoodBytes returns the size of the last-known-good pages whose datasets
+// are not held by the page cache as well.
+func (c *Client) lastGoodBytes() int64 {
+	cached := c.cachedPageItems()
+	c.lastGoodMutex.RLock()
+	defer c.lastGoodMutex.RUnlock()
+	var total int64
+	for key, item := range c.lastGood {
+		if !sharesItems(item.data.Items, cached[key]) {
+			total += int64(item.size)
+		}
+	}
+	return total
+}
+
+// evictLastGood removes the last-known-good pages not held by the page cache,
+// the longest fetched first, until at least want bytes are freed.
+func (c *Client) evictLastGood(want int64) (int64, int) {
+	cached := c.cachedPageItems()
+	c.lastGoodMutex.Lock()
+	defer c.lastGoodMutex.Unlock()
+	unshared := make(map[pageKey]lastGoodItem)
+	for key, item := range c.lastGood {
+		if !sharesItems(item.data.Items, cached[key]) {
+			unshared[key] = item
+		}
+	}
+	candidates := slices.Collect(maps.Keys(unshared))
+	freed, n := EvictEarliest(unshared, lastGoodEntry, want)
+	for _, key := range candidates {
+		if _, kept := unshared[key]; !kept {
+			delete(c.lastGood, key)
+		}
+	}
+	return freed, n
+}
+
+func (c *Client) mobilityBytes() int64 {
+	c.mobilityMutex.Lock()
+	defer c.mobilityMutex.Unlock()
+	return int64(c.mobilitySize)
+}
+
+// evictMobility drops the mobility datasets, which are fetched again on the
+// next use.
+func (c *Client) evictMobility(int64) (int64, int) {
+	c.mobilityMutex.Lock()
+	defer c.mobilityMutex.Unlock()
+	if c.mobilityCache == nil {
+		return 0, 0
+	}
+	freed := c.mobilitySize
+	c.mobilityCache, c.mobilitySize, c.mobilityExpiration = nil, 0, time.Time{}
+	return int64(freed), 1
+}
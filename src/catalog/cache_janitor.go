@@ -0,0 +1,84 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"log"
+	"time"
+)
+
+// StartCacheJanitor removes expired entries from the caches every
+// CACHE_JANITOR_INTERVAL (default 1m), so long-running instances don't
+// accumulate dead pages and details. Pages and details are kept for
+// CACHE_MAX_STALENESS past their expiry, as they may still be served stale.
+func (c *Client) StartCacheJanitor() {
+	interval := c.cfg.Cache.JanitorInterval
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			removed := c.removeExpiredEntries()
+			total := 0
+			for name, n := range removed {
+				c.countExpired(name, n)
+				total += n
+			}
+			if total > 0 {
+				log.Printf("Cache janitor removed %d expired entries: %v", total, removed)
+			}
+		}
+	}()
+}
+
+// removeExpiredEntries drops expired entries from every cache and returns the
+// number removed per cache.
+func (c *Client) removeExpiredEntries() map[string]int {
+	now := time.Now()
+	staleCutoff := now.Add(-c.cfg.Cache.MaxStaleness)
+	removed := map[string]int{}
+
+	removed["pages"] = c.datasetCache.removeExpired(staleCutoff)
+
+	c.detailMutex.Lock()
+	for id, item := range c.detailCache {
+		if item.expiration.Before(staleCutoff) {
+			delete(c.detailCache, id)
+			removed["details"]++
+		}
+	}
+	c.detailMutex.Unlock()
+
+	c.notFoundMutex.Lock()
+	for id, expiration := range c.notFoundCache {
+		if expiration.Before(now) {
+			delete(c.notFoundCache, id)
+			removed["notFound"]++
+		}
+	}
+	c.notFoundMutex.Unlock()
+
+	c.openAPICacheMutex.Lock()
+	for id, item := range c.openAPICache {
+		if item.expiration.Before(now) {
+			delete(c.openAPICache, id)
+			removed["openapi"]++
+		}
+	}
+	c.openAPICacheMutex.Unlock()
+
+	c.documentMutex.Lock()
+	for key, item := range c.documentCache {
+		if item.expiration.Before(now) {
+			delete(c.documentCache, key)
+			removed["documents"]++
+		}
+	}
+	c.documentMutex.Unlock()
+
+	for _, rc := range c.registeredCaches {
+		removed[rc.Name] = rc.RemoveExpired(now)
+	}
+	return removed
+}
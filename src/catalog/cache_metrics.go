@@ -0,0 +1,191 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cacheCounters counts the lookups and evictions of one cache.
+type cacheCounters struct {
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+	// expired counts the entries removed by the cache janitor.
+	expired atomic.Int64
+}
+
+// CountHit and CountMiss count a lookup of cache answered from it and one that
+// required a fetch or a rebuild.
+func (c *Client) CountHit(cache string)  { c.cacheCounts[cache].hits.Add(1) }
+func (c *Client) CountMiss(cache string) { c.cacheCounts[cache].misses.Add(1) }
+
+// CountEvictions counts n entries of cache removed before they were replaced.
+func (c *Client) CountEvictions(cache string, n int) {
+	c.cacheCounts[cache].evictions.Add(int64(n))
+}
+
+func (c *Client) countExpired(cache string, n int) {
+	c.cacheCounts[cache].expired.Add(int64(n))
+}
+
+// CacheStat is the state of one cache, as reported by /admin/cache/stats.
+type CacheStat struct {
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	Evictions int64   `json:"evictions"`
+	Expired   int64   `json:"expired"`
+	HitRatio  float64 `json:"hitRatio"`
+	Entries   int     `json:"entries"`
+	// Ages of the entries in seconds, derived from their expiration. Because of
+	// TTL jitter, they may overestimate the real age by up to cacheJitter.
+	OldestEntryAge  float64 `json:"oldestEntryAgeSeconds"`
+	AverageEntryAge float64 `json:"averageEntryAgeSeconds"`
+	// Bytes is the estimated size of the entries of the caches held against
+	// CACHE_MEMORY_BUDGET, nil for the others.
+	Bytes *int64 `json:"bytes,omitempty"`
+}
+
+// cacheExpirations returns the expiration times of the entries of a cache and
+// the TTL they were stored with.
+func (c *Client) cacheExpirations(cache string) ([]time.Time, time.Duration) {
+	var out []time.Time
+	switch cache {
+	case "pages":
+		out = c.datasetCache.expirations()
+	case "details":
+		c.detailMutex.RLock()
+		for _, item := range c.detailCache {
+			out = append(out, item.expiration)
+		}
+		c.detailMutex.RUnlock()
+	case "notFound":
+		c.notFoundMutex.RLock()
+		for _, expiration := range c.notFoundCache {
+			out = append(out, expiration)
+		}
+		c.notFoundMutex.RUnlock()
+		return out, notFoundTTL
+	case "openapi":
+		c.openAPICacheMutex.RLock()
+		for _, item := range c.openAPICache {
+			out = append(out, item.expiration)
+		}
+		c.openAPICacheMutex.RUnlock()
+	case "documents":
+		c.documentMutex.RLock()
+		for _, item := range c.documentCache {
+			out = append(out, item.expiration)
+		}
+		c.documentMutex.RUnlock()
+	case "index":
+		if idx := c.currentCatalogIndex.Load(); idx != nil {
+			out = append(out, idx.expiration)
+		}
+	case "mobility":
+		c.mobilityMutex.Lock()
+		if c.mobilityCache != nil {
+			out = append(out, c.mobilityExpiration)
+		}
+		c.mobilityMutex.Unlock()
+	case "lastGood":
+		// Last-known-good pages do not expire; their age is the time since
+		// they were fetched.
+		c.lastGoodMutex.RLock()
+		for _, item := range c.lastGood {
+			out = append(out, item.fetched.Add(CacheTTL))
+		}
+		c.lastGoodMutex.RUnlock()
+	default:
+		if rc, ok := c.registeredCache(cache); ok {
+			out = rc.Expirations()
+		}
+	}
+	return out, CacheTTL
+}
+
+// CacheStats returns the current state of every cache.
+func (c *Client) CacheStats() map[string]CacheStat {
+	now := time.Now()
+	stats := make(map[string]CacheStat, len(c.cacheCounts))
+	for name, counters := range c.cacheCounts {
+		stat := CacheStat{
+			Hits:      counters.hits.Load(),
+			Misses:    counters.misses.Load(),
+			Evictions: counters.evictions.Load(),
+			Expired:   counters.expired.Load(),
+		}
+		if lookups := stat.Hits + stat.Misses; lookups > 0 {
+			stat.HitRatio = float64(stat.Hits) / float64(lookups)
+		}
+		expirations, ttl := c.cacheExpirations(name)
+		stat.Entries = len(expirations)
+		var total float64
+		for _, expiration := range expirations {
+			age := (ttl - expiration.Sub(now)).Seconds()
+			total += age
+			if age > stat.OldestEntryAge {
+				stat.OldestEntryAge = age
+			}
+		}
+		if stat.Entries > 0 {
+			stat.AverageEntryAge = total / float64(stat.Entries)
+		}
+		if bytes, budgeted := c.cacheBytes(name); budgeted {
+			stat.Bytes = &bytes
+		}
+		stats[name] = stat
+	}
+	return stats
+}
+
+// cacheCollector exports the CacheStats of client as Prometheus metrics.
+type cacheCollector struct {
+	client *Client
+}
+
+var (
+	cacheHitsDesc      = prometheus.NewDesc("catalog_cache_hits_total", "Cache lookups answered from the cache.", []string{"cache"}, nil)
+	cacheMissesDesc    = prometheus.NewDesc("catalog_cache_misses_total", "Cache lookups that required a fetch.", []string{"cache"}, nil)
+	cacheEvictionsDesc = prometheus.NewDesc("catalog_cache_evictions_total", "Entries removed before being replaced.", []string{"cache"}, nil)
+	cacheExpiredDesc   = prometheus.NewDesc("catalog_cache_expired_total", "Expired entries removed by the cache janitor.", []string{"cache"}, nil)
+	cacheEntriesDesc   = prometheus.NewDesc("catalog_cache_entries", "Number of cached entries.", []string{"cache"}, nil)
+	cacheOldestDesc    = prometheus.NewDesc("catalog_cache_oldest_entry_age_seconds", "Age of the oldest cached entry.", []string{"cache"}, nil)
+	cacheBytesDesc     = prometheus.NewDesc("catalog_cache_bytes", "Estimated size of the entries of the caches held against the memory budget.", []string{"cache"}, nil)
+)
+
+func (cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheExpiredDesc
+	ch <- cacheEntriesDesc
+	ch <- cacheOldestDesc
+	ch <- cacheBytesDesc
+}
+
+func (cc cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := cc.client.CacheStats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stat := stats[name]
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stat.Hits), name)
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stat.Misses), name)
+		ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(stat.Evictions), name)
+		ch <- prometheus.MustNewConstMetric(cacheExpiredDesc, prometheus.CounterValue, float64(stat.Expired), name)
+		ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(stat.Entries), name)
+		ch <- prometheus.MustNewConstMetric(cacheOldestDesc, prometheus.GaugeValue, stat.OldestEntryAge, name)
+		if stat.Bytes != nil {
+			ch <- prometheus.MustNewConstMetric(cacheBytesDesc, prometheus.GaugeValue, float64(*stat.Bytes), name)
+		}
+	}
+}
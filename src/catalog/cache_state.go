@@ -0,0 +1,161 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// cacheStateVersion is increased whenever the layout of cacheState changes;
+// files of other versions are ignored on restore.
+const cacheStateVersion = 3
+
+// cacheState is the on-disk form of the in-memory caches.
+type cacheState struct {
+	Version  int                    `json:"version"`
+	SavedAt  time.Time              `json:"savedAt"`
+	Pages    []savedPage            `json:"pages"`
+	LastGood []savedPage            `json:"lastGood"`
+	Details  map[string]savedDetail `json:"details"`
+}
+
+type savedPage struct {
+	Source     string       `json:"source"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+	Filters    string       `json:"filters,omitempty"`
+	Link       string       `json:"link,omitempty"`
+	Expiration time.Time    `json:"expiration,omitempty"`
+	Data       MetaDataPage `json:"data"`
+}
+
+type savedDetail struct {
+	Dataset    transformers.Dataset `json:"dataset"`
+	Expiration time.Time            `json:"expiration"`
+}
+
+func (p savedPage) key() pageKey {
+	return pageKey{source: p.Source, page: p.Page, pageSize: p.PageSize, filters: p.Filters, link: p.Link}
+}
+
+func toSavedPage(key pageKey, data MetaDataPage, expiration time.Time) savedPage {
+	return savedPage{
+		Source:     key.source,
+		Page:       key.page,
+		PageSize:   key.pageSize,
+		Filters:    key.filters,
+		Link:       key.link,
+		Expiration: expiration,
+		Data:       data,
+	}
+}
+
+// SaveCacheState writes the in-memory page, last-known-good and detail caches
+// to path, to be restored by RestoreCacheState after a restart. Pages held by
+// an external cache backend are not included.
+func (c *Client) SaveCacheState(path string) error {
+	state := cacheState{
+		Version: cacheStateVersion,
+		SavedAt: time.Now(),
+		Details: make(map[string]savedDetail),
+	}
+	if m, ok := c.datasetCache.(*memoryPageCache); ok {
+		m.mu.RLock()
+		for key, item := range m.items {
+			state.Pages = append(state.Pages, toSavedPage(key, *item.page(key), item.expiration))
+		}
+		m.mu.RUnlock()
+	}
+	c.lastGoodMutex.RLock()
+	for key, item := range c.lastGood {
+		state.LastGood = append(state.LastGood, toSavedPage(key, *item.data, time.Time{}))
+	}
+	c.lastGoodMutex.RUnlock()
+	c.detailMutex.RLock()
+	for id, item := range c.detailCache {
+		state.Details[id] = savedDetail{Dataset: *item.data, Expiration: item.expiration}
+	}
+	c.detailMutex.RUnlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so an interrupted save never leaves a
+	// truncated state behind.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	log.Printf("Saved cache state: %d pages, %d last known good pages, %d details", len(state.Pages), len(state.LastGood), len(state.Details))
+	return nil
+}
+
+// RestoreCacheState loads a file written by SaveCacheState. Files of another
+// version or older than CACHE_MAX_STALENESS are ignored, as are entries for
+// other upstream sources, invalid page sizes and expired details. The restored
+// datasets get the current dataset overrides. A missing file is not an error.
+func (c *Client) RestoreCacheState(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state cacheState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Version != cacheStateVersion {
+		return fmt.Errorf("unsupported cache state version %d", state.Version)
+	}
+	maxStaleness := c.cfg.Cache.MaxStaleness
+	if time.Since(state.SavedAt) > maxStaleness {
+		return fmt.Errorf("cache state saved at %s is too old", state.SavedAt.Format(time.RFC3339))
+	}
+
+	now := time.Now()
+	valid := func(p savedPage) bool {
+		return p.Source == metaDataURL && (p.Link == "" || sameHost(p.Link, metaDataURL)) && p.Page >= 1 && p.PageSize >= 1 && p.PageSize <= max(MaxPageSize, c.upstreamPageSize())
+	}
+	pages, good, details := 0, 0, 0
+	for _, p := range state.Pages {
+		if valid(p) && now.Before(p.Expiration.Add(maxStaleness)) {
+			c.datasetCache.set(p.key(), cacheItem{data: c.applyOverrides(p.Data.Items), totalResults: p.Data.TotalResults, nextPage: p.Data.NextPage, expiration: p.Expiration})
+			pages++
+		}
+	}
+	c.lastGoodMutex.Lock()
+	for _, p := range state.LastGood {
+		if valid(p) {
+			page := p.Data
+			page.Items = c.applyOverrides(page.Items)
+			c.lastGood[p.key()] = lastGoodItem{data: &page, size: encodedSize(page.Items), fetched: state.SavedAt}
+			good++
+		}
+	}
+	c.lastGoodMutex.Unlock()
+	c.detailMutex.Lock()
+	for id, d := range state.Details {
+		if id == d.Dataset.ID && now.Before(d.Expiration) {
+			ds := c.applyOverride(d.Dataset)
+			c.detailCache[id] = detailItem{data: &ds, expiration: d.Expiration, size: encodedSize(&ds)}
+			details++
+		}
+	}
+	c.detailMutex.Unlock()
+	c.EnforceCacheBudget()
+	log.Printf("Restored cache state from %s: %d pages, %d last known good pages, %d details", state.SavedAt.Format(time.RFC3339), pages, good, details)
+	return nil
+}
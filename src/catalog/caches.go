@@ -0,0 +1,108 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import "time"
+
+// Cache is a cache of an importing package, such as the rendered responses of
+// the HTTP server, managed along with the caches of the catalog: it is
+// reported in the cache statistics, held against CACHE_MEMORY_BUDGET, cleaned
+// by the cache janitor and emptied by Flush. Its entries are derived from the
+// catalog, so they are evicted before those of the catalog.
+type Cache struct {
+	Name string
+	// Expirations returns the expiration times of the entries.
+	Expirations func() []time.Time
+	// Bytes returns the estimated size of the entries.
+	Bytes func() int64
+	// Evict removes the entries expiring first until at least want bytes are
+	// freed, and returns the bytes freed and the number of entries removed.
+	Evict func(want int64) (int64, int)
+	// RemoveExpired removes the entries expired at now and returns how many.
+	RemoveExpired func(now time.Time) int
+	// Flush removes the entries that may hold the data of upstream page (if
+	// not 0) or of the dataset id (if not empty), or every entry if neither
+	// is given, and returns how many.
+	Flush func(page int, id string) int
+}
+
+// RegisterCache adds c to the caches managed by the package. Call it during
+// initialization, before any cache is used.
+func (c *Client) RegisterCache(cache Cache) {
+	c.registeredCaches = append(c.registeredCaches, cache)
+	c.cacheCounts[cache.Name] = &cacheCounters{}
+	c.budgetedCaches = append([]budgetedCache{{cache.Name, cache.Bytes, cache.Evict}}, c.budgetedCaches...)
+}
+
+// registeredCache returns the registered cache with the given name.
+func (c *Client) registeredCache(name string) (Cache, bool) {
+	for _, rc := range c.registeredCaches {
+		if rc.Name == name {
+			return rc, true
+		}
+	}
+	return Cache{}, false
+}
+
+// Flush drops the cached data of upstream page (if not 0) or of the dataset id
+// (if not empty), or everything if neither is given, including the entries of
+// the caches added by RegisterCache, and returns the number of dropped entries
+// per cache.
+func (c *Client) Flush(page int, id string) map[string]int {
+	all := page == 0 && id == ""
+	flushed := map[string]int{}
+
+	flushed["pages"] = c.datasetCache.remove(func(key pageKey) bool {
+		return all || key.page == page
+	})
+
+	c.detailMutex.Lock()
+	for key := range c.detailCache {
+		if all || key == id {
+			delete(c.detailCache, key)
+			flushed["details"]++
+		}
+	}
+	c.detailMutex.Unlock()
+
+	c.notFoundMutex.Lock()
+	for key := range c.notFoundCache {
+		if all || key == id {
+			delete(c.notFoundCache, key)
+			flushed["notFound"]++
+		}
+	}
+	c.notFoundMutex.Unlock()
+
+	c.openAPICacheMutex.Lock()
+	for key := range c.openAPICache {
+		if all || key == id {
+			delete(c.openAPICache, key)
+			flushed["openapi"]++
+		}
+	}
+	c.openAPICacheMutex.Unlock()
+
+	c.documentMutex.Lock()
+	for key := range c.documentCache {
+		if all || key.id == id {
+			delete(c.documentCache, key)
+			flushed["documents"]++
+		}
+	}
+	c.documentMutex.Unlock()
+
+	// The index holds the datasets of the flushed pages and details, so it is
+	// dropped and rebuilt by the next complete walk.
+	c.currentCatalogIndex.Store(nil)
+
+	for _, rc := range c.registeredCaches {
+		flushed[rc.Name] = rc.Flush(page, id)
+	}
+
+	for name, n := range flushed {
+		c.CountEvictions(name, n)
+	}
+	return flushed
+}
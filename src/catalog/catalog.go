@@ -0,0 +1,856 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// MaxPageSize is the largest number of datasets in a page of a listing.
+const MaxPageSize = 100
+
+// upstreamPageSize returns the number of datasets fetched per upstream call,
+// UPSTREAM_PAGE_SIZE (default 100). API pages are sliced from these pages, so
+// sequential harvesters paging through the catalog need far fewer upstream calls.
+func (c *Client) upstreamPageSize() int {
+	return c.cfg.Upstream.PageSize
+}
+
+// CacheTTL is how long cached upstream data and responses are considered fresh.
+const CacheTTL = 5 * time.Minute
+
+// cacheJitter is the largest random reduction applied to CacheTTL, so entries
+// cached together do not all expire at once.
+const cacheJitter = CacheTTL / 5
+
+// jitteredTTL returns CacheTTL shortened by a random amount of up to cacheJitter.
+func jitteredTTL() time.Duration {
+	return CacheTTL - rand.N(cacheJitter)
+}
+
+// refreshStagger is the longest random delay before a background refresh, which
+// spreads the refreshes of pages that expired together.
+const refreshStagger = 5 * time.Second
+
+type cacheItem struct {
+	data []transformers.Dataset
+	// totalResults is the number of datasets in the whole upstream catalog.
+	totalResults int
+	// nextPage is the upstream link to the following page, if any.
+	nextPage   string
+	expiration time.Time
+	// size is the memory held by data, counted against CACHE_MEMORY_BUDGET
+	// by the memory backend.
+	size int
+	// kept counts the datasets of data kept by the listing policies of
+	// catalogPage; set by the memory backend.
+	kept *keptCounts
+}
+
+// page returns the cached page as the upstream response it was stored from.
+func (it cacheItem) page(key pageKey) *MetaDataPage {
+	return &MetaDataPage{
+		TotalResults: it.totalResults,
+		TotalPages:   (it.totalResults + key.pageSize - 1) / key.pageSize,
+		CurrentPage:  key.page,
+		NextPage:     it.nextPage,
+		Items:        it.data,
+		kept:         it.kept,
+		expiration:   it.expiration,
+	}
+}
+
+// metaDataURL is the upstream MetaData API endpoint.
+const metaDataURL = "https://tourism.api.opendatahub.com/v1/MetaData"
+
+// pageKey identifies a cached upstream page by every dimension of the upstream
+// request, so pages fetched with different sources or filters never collide.
+type pageKey struct {
+	// source is the upstream endpoint URL.
+	source   string
+	page     int
+	pageSize int
+	// filters are the additional upstream query parameters in canonical
+	// (url.Values.Encode) form, empty for none.
+	filters string
+	// link is the upstream URL of the page as given by the NextPage link of
+	// the page before, if it differs from the URL built from the other fields.
+	link string
+}
+
+// newPageKey returns the key of a page of the default upstream source.
+// filters may be nil.
+func newPageKey(page, pageSize int, filters url.Values) pageKey {
+	return pageKey{source: metaDataURL, page: page, pageSize: pageSize, filters: filters.Encode()}
+}
+
+// withPage returns the key of another page of the same listing.
+func (k pageKey) withPage(page int) pageKey {
+	return pageKey{source: k.source, page: page, pageSize: k.pageSize, filters: k.filters}
+}
+
+// url returns the upstream URL of the page.
+func (k pageKey) url() string {
+	if k.link != "" {
+		return k.link
+	}
+	q, _ := url.ParseQuery(k.filters)
+	q.Set("pagenumber", strconv.Itoa(k.page))
+	q.Set("limit", strconv.Itoa(k.pageSize))
+	return k.source + "?" + q.Encode()
+}
+
+// recordSync notes a successful upstream fetch.
+func (c *Client) recordSync() {
+	c.lastSyncMutex.Lock()
+	c.lastSync = time.Now()
+	c.lastSyncMutex.Unlock()
+}
+
+// recordFailure notes a failed upstream fetch.
+func (c *Client) recordFailure() {
+	c.lastSyncMutex.Lock()
+	c.lastFailure = time.Now()
+	c.lastSyncMutex.Unlock()
+}
+
+// UpstreamFailing reports whether the most recent upstream fetch failed, in
+// which case responses may be built from last-known-good data.
+func (c *Client) UpstreamFailing() bool {
+	c.lastSyncMutex.RLock()
+	defer c.lastSyncMutex.RUnlock()
+	return c.lastFailure.After(c.lastSync)
+}
+
+// LastSyncTime returns the time of the last successful upstream fetch (zero if none).
+func (c *Client) LastSyncTime() time.Time {
+	c.lastSyncMutex.RLock()
+	defer c.lastSyncMutex.RUnlock()
+	return c.lastSync
+}
+
+// MetaDataPage is one page of the upstream MetaData API response.
+type MetaDataPage struct {
+	TotalResults int                    `json:"TotalResults"`
+	TotalPages   int                    `json:"TotalPages"`
+	CurrentPage  int                    `json:"CurrentPage"`
+	NextPage     string                 `json:"NextPage"`
+	Items        []transformers.Dataset `json:"Items"`
+
+	// stale marks a last-known-good copy served because the upstream API
+	// failed, or an expired cached page served while it is refreshed.
+	stale bool
+	// kept is the kept-count index of the cached page, nil for pages not
+	// held in memory.
+	kept *keptCounts
+	// expiration is the expiration of the cached page, zero for pages that
+	// were not cached.
+	expiration time.Time
+}
+
+// lastGoodItem is the most recent successful response of a page, fetched at
+// fetched, with the estimated size of its datasets.
+type lastGoodItem struct {
+	data    *MetaDataPage
+	size    int
+	fetched time.Time
+}
+
+// lastKnownGood answers a failed upstream fetch of key with the most recent
+// successful response, from memory, the persistent cache or else the startup
+// snapshot. It returns fetchErr if none of them has the page.
+func (c *Client) lastKnownGood(key pageKey, fetchErr error) (*MetaDataPage, error) {
+	c.recordFailure()
+	c.lastGoodMutex.RLock()
+	item, found := c.lastGood[key]
+	c.lastGoodMutex.RUnlock()
+	data := item.data
+	if found {
+		c.CountHit("lastGood")
+		log.Printf("Upstream unavailable (%v), serving last known good page %d", fetchErr, key.page)
+	} else {
+		c.CountMiss("lastGood")
+		if persisted, err := c.loadPersistedPage(key, fetchErr); err == nil {
+			data = persisted
+		} else if data = c.snapshotPage(key); data != nil {
+			log.Printf("Upstream unavailable (%v), serving page %d from snapshot", fetchErr, key.page)
+		} else {
+			return nil, fetchErr
+		}
+	}
+	stale := *data
+	stale.stale = true
+	return &stale, nil
+}
+
+// fetchUpstreamPage retrieves a page from the external API. Successful
+// responses of unfiltered pages are kept as last-known-good copies, in memory
+// and in the persistent cache, which answer instead when the upstream API is
+// unavailable; filtered pages are not, as clients can request any number of
+// filters. A fetch abandoned because ctx is done just returns the error. In
+// offline mode, pages come from the fixtures instead.
+func (c *Client) fetchUpstreamPage(ctx context.Context, key pageKey) (*MetaDataPage, error) {
+	if c.OfflineMode() {
+		if data := c.snapshotPage(key); data != nil {
+			return data, nil
+		}
+		return nil, errOffline
+	}
+	resp, err := c.upstreamGet(ctx, endpointList, key.url())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return c.lastKnownGood(key, err)
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return c.lastKnownGood(key, fmt.Errorf("unexpected status %d", resp.StatusCode))
+	}
+
+	data, err := c.decodeMetaDataPage(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("Error decoding JSON on page %d: %v", key.page, err)
+		return c.lastKnownGood(key, err)
+	}
+	c.recordSync()
+	data.Items = c.applyOverrides(data.Items)
+	if key.filters == "" {
+		c.lastGoodMutex.Lock()
+		c.lastGood[key] = lastGoodItem{data: data, size: encodedSize(data.Items), fetched: time.Now()}
+		c.lastGoodMutex.Unlock()
+		c.persistPage(key, data)
+	}
+	return data, nil
+}
+
+// fetchDatasets retrieves an upstream page from the external API, caching the
+// result for 5 minutes. Expired pages are still served for up to
+// CACHE_MAX_STALENESS (default 1h) while they are refreshed in the background.
+// A page being prefetched is awaited instead of fetched again.
+func (c *Client) fetchDatasets(ctx context.Context, key pageKey) (*MetaDataPage, error) {
+	item, found := c.datasetCache.get(key)
+	if !found && c.awaitPrefetch(ctx, key) {
+		item, found = c.datasetCache.get(key)
+	}
+	if found {
+		now := time.Now()
+		if now.Before(item.expiration) {
+			c.CountHit("pages")
+			c.notePrefetchHit(key)
+			noteExpiration(ctx, item.expiration)
+			return item.page(key), nil
+		}
+		if now.Before(item.expiration.Add(c.cfg.Cache.MaxStaleness)) {
+			c.CountHit("pages")
+			c.refreshDatasetsAsync(key)
+			noteExpiration(ctx, item.expiration)
+			data := item.page(key)
+			data.stale = true
+			return data, nil
+		}
+	}
+	c.CountMiss("pages")
+	data, err := c.refreshDatasets(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data.stale {
+		noteExpiration(ctx, time.Now())
+	} else {
+		noteExpiration(ctx, data.expiration)
+	}
+	return data, nil
+}
+
+// refreshDatasetsAsync refreshes a cached page in the background, unless a
+// refresh of it is already running.
+func (c *Client) refreshDatasetsAsync(key pageKey) {
+	c.cacheMutex.Lock()
+	if c.refreshing[key] {
+		c.cacheMutex.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.cacheMutex.Unlock()
+
+	go func() {
+		time.Sleep(rand.N(refreshStagger))
+		if _, err := c.refreshDatasets(context.Background(), key); err != nil {
+			log.Printf("Error refreshing page %d in the background: %v", key.page, err)
+		}
+		c.cacheMutex.Lock()
+		delete(c.refreshing, key)
+		c.cacheMutex.Unlock()
+	}()
+}
+
+// refreshDatasets fetches a page from the external API and caches it. Pages
+// past the end of the catalog are not cached.
+func (c *Client) refreshDatasets(ctx context.Context, key pageKey) (*MetaDataPage, error) {
+	data, err := c.fetchUpstreamPage(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Items) == 0 {
+		log.Printf("No datasets found on page %d", key.page)
+		return data, nil
+	}
+	c.storePage(key, data)
+	return data, nil
+}
+
+// storePage caches a fetched page and sets its expiration. Fallback data is
+// not cached as fresh, so the next request retries the upstream API.
+func (c *Client) storePage(key pageKey, data *MetaDataPage) {
+	if data.stale {
+		return
+	}
+	c.invalidateChangedDetails(data.Items)
+	data.expiration = time.Now().Add(jitteredTTL())
+	c.datasetCache.set(key, cacheItem{
+		data:         data.Items,
+		totalResults: data.TotalResults,
+		nextPage:     data.NextPage,
+		expiration:   data.expiration,
+	})
+	c.EnforceCacheBudget()
+}
+
+// Page returns page of the catalog, matching the upstream
+// filters (nil for none) and kept by the ?deprecated= policy deprecated,
+// split into pages of pageSize datasets. With
+// deprecated "include", the datasets are sliced from the cached upstream
+// pages of upstreamPageSize datasets covering them, followed without filters
+// by the datasets of the mobility API, as in ForEachPage. Otherwise, and for
+// a ctx limited to some dataspaces, the page and its totals are
+// computed by catalogPage from the datasets kept on the upstream pages, so
+// pages are never short and the totals only count datasets that are listed.
+// It returns nil if the page is empty. Requests walking the upstream pages in
+// order prefetch the pages after them with prefetchAfter.
+func (c *Client) Page(ctx context.Context, page, pageSize int, filters url.Values, deprecated string) (*MetaDataPage, error) {
+	if deprecated != "include" || dataspacesFrom(ctx).Restricted() {
+		return c.catalogPage(ctx, page, pageSize, filters, deprecated)
+	}
+	size := c.upstreamPageSize()
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	var mobility []transformers.Dataset
+	if filters == nil {
+		mobility = c.mobilityDatasets(ctx)
+	}
+	// Pages past the end of the catalog are not cached, so they are answered
+	// from the total of the cached first page instead of the upstream API.
+	total, known := c.cachedTotal(filters)
+	if known && start >= total+len(mobility) {
+		return nil, nil
+	}
+	if !known && len(mobility) > 0 {
+		// The mobility datasets follow the upstream ones, so the number of
+		// upstream datasets is needed to place them.
+		data, err := c.fetchDatasets(ctx, newPageKey(1, size, filters))
+		if err != nil {
+			return nil, err
+		}
+		total, known = data.TotalResults, true
+	}
+	resp := &MetaDataPage{CurrentPage: page, TotalResults: total}
+	lastUpstream := (total + size - 1) / size
+	if !known || start < total {
+		// The upstream pages are read up to the known end of the upstream
+		// datasets, as pages past it are not cached.
+		upstreamEnd := end
+		if known {
+			upstreamEnd = min(end, total)
+		}
+		for upstreamPage := start/size + 1; upstreamPage <= (upstreamEnd-1)/size+1; upstreamPage++ {
+			data, err := c.fetchDatasets(ctx, newPageKey(upstreamPage, size, filters))
+			if err != nil {
+				return nil, err
+			}
+			lastUpstream = upstreamPage
+			resp.TotalResults = data.TotalResults
+			resp.stale = resp.stale || data.stale
+			offset := (upstreamPage - 1) * size
+			if from, to := max(start-offset, 0), min(end-offset, len(data.Items)); from < to {
+				resp.Items = append(resp.Items, data.Items[from:to]...)
+			}
+			if len(data.Items) < size {
+				break
+			}
+		}
+	}
+	upstreamTotal := resp.TotalResults
+	if from, to := max(start-upstreamTotal, 0), min(end-upstreamTotal, len(mobility)); from < to {
+		resp.Items = append(resp.Items, mobility[from:to]...)
+	}
+	if len(resp.Items) == 0 {
+		return nil, nil
+	}
+	resp.TotalResults += len(mobility)
+	resp.TotalPages = (resp.TotalResults + pageSize - 1) / pageSize
+	c.prefetchAfter(page, pageSize, filters, lastUpstream, upstreamTotal)
+	return resp, nil
+}
+
+// cachedTotal returns the number of datasets matching the upstream filters
+// according to the fresh cached first upstream page, or false if there is none.
+func (c *Client) cachedTotal(filters url.Values) (int, bool) {
+	item, found := c.datasetCache.get(newPageKey(1, c.upstreamPageSize(), filters))
+	if !found || time.Now().After(item.expiration) {
+		return 0, false
+	}
+	return item.totalResults, true
+}
+
+// catalogPage returns page of the datasets of the catalog matching the
+// upstream filters that the dataspaces of ctx and the ?deprecated= policy
+// deprecated keep, split into pages of pageSize datasets, or nil if the page
+// is empty. The page
+// is sliced from the cached upstream pages matching the filters, followed
+// without filters by the datasets of the mobility API, read in order up to the
+// one completing it; only the pages covering it are filtered once their
+// kept-count index knows how many datasets they keep. The first request of a
+// policy reads every upstream page instead, to count the datasets it keeps in
+// the whole catalog, which the first page then holds for the totals of the
+// next ones. Like Page, requests walking the listing in order prefetch the
+// upstream pages after the last one they read with prefetchAfter.
+func (c *Client) catalogPage(ctx context.Context, page, pageSize int, filters url.Values, deprecated string) (*MetaDataPage, error) {
+	dataspaces := dataspacesFrom(ctx)
+	policy := listingPolicy(dataspaces, deprecated)
+	keep := func(items []transformers.Dataset) []transformers.Dataset {
+		return FilterDeprecated(deprecated, dataspaces.Filter(items))
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	resp := &MetaDataPage{CurrentPage: page}
+	// total is the number of datasets kept by the pages read so far.
+	total := 0
+	add := func(items []transformers.Dataset) {
+		if from, to := max(start-total, 0), min(end-total, len(items)); from < to {
+			resp.Items = append(resp.Items, items[from:to]...)
+		}
+		total += len(items)
+	}
+
+	size := c.upstreamPageSize()
+	first, err := c.fetchDatasets(ctx, newPageKey(1, size, filters))
+	if err != nil {
+		return nil, err
+	}
+	fetch := func(ctx context.Context, upstreamPage int) (*MetaDataPage, error) {
+		if upstreamPage == 1 {
+			return first, nil
+		}
+		return c.fetchDatasets(ctx, newPageKey(upstreamPage, size, filters))
+	}
+	visit := func(_ int, data *MetaDataPage) error {
+		resp.stale = resp.stale || data.stale
+		if n, known := data.kept.get(policy); known && (total+n <= start || total >= end) {
+			total += n
+			return nil
+		}
+		items := keep(data.Items)
+		data.kept.set(policy, len(items))
+		add(items)
+		return nil
+	}
+	last := max((first.TotalResults+size-1)/size, 1)
+	catalogTotal, counted := first.kept.total(policy)
+	// As in Page, pages past the end of a counted catalog are answered from
+	// its total instead of the upstream pages.
+	if counted && start >= catalogTotal {
+		return nil, nil
+	}
+	lastUpstream := last
+	if counted {
+		for upstreamPage := 1; upstreamPage <= last && total < end; upstreamPage++ {
+			data, err := fetch(ctx, upstreamPage)
+			if err != nil {
+				return nil, err
+			}
+			visit(upstreamPage, data)
+			lastUpstream = upstreamPage
+		}
+		if filters == nil && total < end {
+			add(keep(c.mobilityDatasets(ctx)))
+		}
+	} else {
+		if err := fetchPages(ctx, c.fetchWorkers(), 1, last, fetch, visit); err != nil {
+			return nil, err
+		}
+		if filters == nil {
+			add(keep(c.mobilityDatasets(ctx)))
+		}
+		catalogTotal = total
+		// Totals counted from last-known-good pages are not kept, so they
+		// are counted again once the upstream API recovers.
+		if item, found := c.datasetCache.get(newPageKey(1, size, filters)); found && !resp.stale {
+			item.kept.setTotal(policy, catalogTotal)
+		}
+	}
+	if start >= total {
+		return nil, nil
+	}
+	resp.TotalResults = catalogTotal
+	resp.TotalPages = (catalogTotal + pageSize - 1) / pageSize
+	c.prefetchAfter(page, pageSize, filters, lastUpstream, first.TotalResults)
+	return resp, nil
+}
+
+// fetchWorkers returns the number of upstream pages fetched in parallel when
+// aggregating the full catalog, FETCH_WORKERS (default 4).
+func (c *Client) fetchWorkers() int {
+	return c.cfg.Upstream.FetchWorkers
+}
+
+type pageResult[T any] struct {
+	value T
+	err   error
+}
+
+// fetchPages fetches the pages first to last with a pool of workers
+// goroutines and calls fn with each page in page order. At most twice as many
+// pages as workers are fetched ahead of fn, so a slow fn, such as a client
+// reading a dump slowly, holds back the upstream requests instead of piling
+// up pages in memory. It stops at the first error of fetch or fn, cancelling
+// the remaining fetches.
+func fetchPages[T any](ctx context.Context, workers, first, last int, fetch func(ctx context.Context, page int) (T, error), fn func(page int, value T) error) error {
+	if last < first {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan pageResult[T], last-first+1)
+	for i := range results {
+		results[i] = make(chan pageResult[T], 1)
+	}
+	workers = min(workers, len(results))
+	// window holds a token for every page fetched but not yet passed to fn.
+	window := make(chan struct{}, 2*workers)
+	pages := make(chan int)
+	go func() {
+		defer close(pages)
+		for page := first; page <= last; page++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for range workers {
+		go func() {
+			for page := range pages {
+				value, err := fetch(ctx, page)
+				results[page-first] <- pageResult[T]{value: value, err: err}
+			}
+		}()
+	}
+
+	for i, result := range results {
+		var r pageResult[T]
+		select {
+		case r = <-result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return r.err
+		}
+		if err := fn(first+i, r.value); err != nil {
+			return err
+		}
+		<-window
+	}
+	return nil
+}
+
+// ForEachPage walks every upstream page through the page cache with walkPages
+// and calls fn with each page's items in page order, followed by the datasets
+// of the mobility API, if configured. Only the datasets of the dataspaces of
+// ctx are passed to fn. A complete walk of fresh pages refreshes the catalog
+// index used by Dataset for CacheTTL.
+func (c *Client) ForEachPage(ctx context.Context, fn func(items []transformers.Dataset) error) error {
+	dataspaces := dataspacesFrom(ctx)
+	index := newCatalogIndex()
+	stale := false
+	err := c.walkPages(ctx, newPageKey(1, c.upstreamPageSize(), nil), c.fetchDatasets, func(_ pageKey, data *MetaDataPage) error {
+		if items := dataspaces.Filter(data.Items); len(items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		index.add(data.Items)
+		stale = stale || data.stale
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if mobility := c.mobilityDatasets(ctx); len(mobility) > 0 {
+		if items := dataspaces.Filter(mobility); len(items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		index.add(mobility)
+	}
+	if !stale {
+		c.setCatalogIndex(index, time.Now().Add(CacheTTL))
+	}
+	return nil
+}
+
+// AllDatasets returns the complete upstream catalog.
+func (c *Client) AllDatasets(ctx context.Context) ([]transformers.Dataset, error) {
+	var all []transformers.Dataset
+	err := c.ForEachPage(ctx, func(items []transformers.Dataset) error {
+		all = append(all, items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// FilterDeprecated applies the ?deprecated= policy to datasets: "include" keeps
+// everything, "only" keeps deprecated datasets, and anything else (the default,
+// "exclude") drops them.
+func FilterDeprecated(mode string, datasets []transformers.Dataset) []transformers.Dataset {
+	if mode == "include" {
+		return datasets
+	}
+	var out []transformers.Dataset
+	for _, ds := range datasets {
+		if keepDeprecated(mode, ds.Deprecated) {
+			out = append(out, ds)
+		}
+	}
+	return out
+}
+
+// keepDeprecated reports whether a dataset with the given deprecation status
+// passes the ?deprecated= policy.
+func keepDeprecated(mode string, deprecated bool) bool {
+	if mode == "include" {
+		return true
+	}
+	return deprecated == (mode == "only")
+}
+
+// slugify converts a string into a slug.
+func slugify(s string) string {
+	s = strings.ToLower(s)
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, " ", "-")
+	re := regexp.MustCompile(`[^a-z0-9\-]`)
+	return re.ReplaceAllString(s, "")
+}
+
+// notFoundTTL is how long an ID the upstream API answered 404 for is remembered,
+// for at most maxNotFoundEntries IDs at a time.
+const (
+	notFoundTTL        = time.Minute
+	maxNotFoundEntries = 10000
+)
+
+// knownNotFound reports whether id recently resulted in an upstream 404.
+func (c *Client) knownNotFound(id string) bool {
+	c.notFoundMutex.RLock()
+	expiration, found := c.notFoundCache[id]
+	c.notFoundMutex.RUnlock()
+	if found && time.Now().Before(expiration) {
+		c.CountHit("notFound")
+		return true
+	}
+	c.CountMiss("notFound")
+	return false
+}
+
+// rememberNotFound caches an upstream 404 for id. Expired entries are dropped
+// whenever the cache grows past maxNotFoundEntries, bounding its size.
+func (c *Client) rememberNotFound(id string) {
+	c.notFoundMutex.Lock()
+	defer c.notFoundMutex.Unlock()
+	now := time.Now()
+	if len(c.notFoundCache) >= maxNotFoundEntries {
+		for key, expiration := range c.notFoundCache {
+			if now.After(expiration) {
+				delete(c.notFoundCache, key)
+				c.CountEvictions("notFound", 1)
+			}
+		}
+	}
+	if len(c.notFoundCache) < maxNotFoundEntries {
+		c.notFoundCache[id] = now.Add(notFoundTTL)
+	}
+}
+
+// detailItem is a cached dataset detail.
+type detailItem struct {
+	data       *transformers.Dataset
+	expiration time.Time
+	// size is the memory held by data, counted against CACHE_MEMORY_BUDGET.
+	size int
+}
+
+// invalidateDetail drops the cached detail of the dataset with the given ID.
+func (c *Client) invalidateDetail(id string) {
+	c.detailMutex.Lock()
+	if _, found := c.detailCache[id]; found {
+		delete(c.detailCache, id)
+		c.CountEvictions("details", 1)
+	}
+	c.detailMutex.Unlock()
+}
+
+// invalidateChangedDetails drops cached details that are older than the
+// corresponding datasets of a freshly fetched page, on this and, via
+// PublishInvalidation, on all other instances.
+func (c *Client) invalidateChangedDetails(items []transformers.Dataset) {
+	var changed []string
+	c.detailMutex.Lock()
+	for _, ds := range items {
+		if item, found := c.detailCache[ds.ID]; found && item.data.LastChange != ds.LastChange {
+			delete(c.detailCache, ds.ID)
+			c.CountEvictions("details", 1)
+			changed = append(changed, ds.ID)
+		}
+	}
+	c.detailMutex.Unlock()
+	for _, id := range changed {
+		c.PublishInvalidation(0, id)
+	}
+}
+
+// Dataset returns the details of the dataset with the given ID or slug, or nil
+// if it does not exist, cannot be fetched or lies outside the dataspaces of
+// ctx.
+func (c *Client) Dataset(ctx context.Context, id string) *transformers.Dataset {
+	ds := c.lookupDataset(ctx, id)
+	if ds == nil || !dataspacesFrom(ctx).Includes(*ds) {
+		return nil
+	}
+	return ds
+}
+
+// lookupDataset returns the details of the dataset with the given ID, or nil
+// if it does not exist or cannot be fetched. After a complete walk of the
+// catalog, details are resolved from the catalog index, which also resolves
+// slugs, without an upstream call. Otherwise they are cached for 5 minutes and
+// served past that while the upstream API is unavailable. Unknown IDs are cached
+// for notFoundTTL so repeated requests for them don't reach the upstream API.
+// IDs with mobilityIDPrefix are resolved by the mobility source.
+func (c *Client) lookupDataset(ctx context.Context, id string) *transformers.Dataset {
+	if strings.HasPrefix(id, mobilityIDPrefix) {
+		return c.mobilityDataset(ctx, id)
+	}
+	if ds := c.indexedDataset(ctx, id); ds != nil {
+		c.CountHit("details")
+		return ds
+	}
+	if c.knownNotFound(id) {
+		return nil
+	}
+	c.detailMutex.RLock()
+	item, found := c.detailCache[id]
+	c.detailMutex.RUnlock()
+	if found && time.Now().Before(item.expiration) {
+		c.CountHit("details")
+		noteExpiration(ctx, item.expiration)
+		return item.data
+	}
+	c.CountMiss("details")
+
+	ds, err := c.fetchDatasetDetail(ctx, id)
+	if err != nil {
+		if found {
+			log.Printf("Serving cached detail for ID %s: %v", id, err)
+			noteExpiration(ctx, time.Now())
+			return item.data
+		}
+		return c.snapshotDataset(id)
+	}
+	if ds == nil {
+		c.invalidateDetail(id)
+		c.rememberNotFound(id)
+		return nil
+	}
+	expiration := time.Now().Add(jitteredTTL())
+	noteExpiration(ctx, expiration)
+	c.detailMutex.Lock()
+	c.detailCache[id] = detailItem{
+		data:       ds,
+		expiration: expiration,
+		size:       encodedSize(ds),
+	}
+	c.detailMutex.Unlock()
+	c.EnforceCacheBudget()
+	return ds
+}
+
+// fetchDatasetDetail fetches the dataset details directly from the external API
+// using the given ID. It returns nil without error if the upstream API answers 404.
+// In offline mode, the dataset comes from the fixtures instead.
+func (c *Client) fetchDatasetDetail(ctx context.Context, id string) (*transformers.Dataset, error) {
+	if c.OfflineMode() {
+		return c.snapshotDataset(id), nil
+	}
+	log.Printf("Directly fetching dataset detail for ID: %s", id)
+	resp, err := c.upstreamGet(ctx, endpointDetail, metaDataURL+"/"+url.PathEscape(id))
+	if err != nil {
+		log.Printf("Error fetching detail for ID %s: %v", id, err)
+		return nil, err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		log.Printf("Dataset with ID %s not found (404)", id)
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Error fetching detail for ID %s: status %d", id, resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading detail for ID %s: %v", id, err)
+		return nil, err
+	}
+	var ds transformers.Dataset
+	if err := json.Unmarshal(body, &ds); err != nil {
+		log.Printf("Error decoding dataset detail for ID %s: %v", id, err)
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(body, &raw) == nil {
+		c.checkDatasetDrift(raw)
+	}
+	log.Printf("Dataset found: ID: %s, Shortname: %s", ds.ID, ds.Shortname)
+	ds = c.applyOverride(ds)
+	return &ds, nil
+}
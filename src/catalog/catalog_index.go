@@ -0,0 +1,92 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// catalogIndex indexes datasets by ID and by the slug of their Shortname
+// (e.g. "weather-forecast"), so detail and batch lookups resolve in constant
+// time, without the page cache or the upstream API. Indexes are built once and
+// only read afterwards.
+type catalogIndex struct {
+	byID   map[string]*transformers.Dataset
+	bySlug map[string]*transformers.Dataset
+	// expiration is when the datasets of the index are too old to be served.
+	expiration time.Time
+}
+
+func newCatalogIndex() *catalogIndex {
+	return &catalogIndex{byID: make(map[string]*transformers.Dataset)}
+}
+
+// add indexes items, which must not be modified afterwards.
+func (idx *catalogIndex) add(items []transformers.Dataset) {
+	for i := range items {
+		idx.byID[items[i].ID] = &items[i]
+	}
+}
+
+// indexSlugs indexes the datasets added by their slugs. Slugs shared by
+// several datasets or equal to a dataset ID are left out, so a slug always
+// names a single dataset.
+func (idx *catalogIndex) indexSlugs() {
+	idx.bySlug = make(map[string]*transformers.Dataset)
+	ambiguous := make(map[string]bool)
+	for _, ds := range idx.byID {
+		slug := slugify(ds.Shortname)
+		if slug == "" || idx.byID[slug] != nil || ambiguous[slug] {
+			continue
+		}
+		if idx.bySlug[slug] != nil {
+			delete(idx.bySlug, slug)
+			ambiguous[slug] = true
+			continue
+		}
+		idx.bySlug[slug] = ds
+	}
+}
+
+// lookup returns the dataset with the given ID or slug, or nil.
+func (idx *catalogIndex) lookup(id string) *transformers.Dataset {
+	if idx == nil {
+		return nil
+	}
+	if ds := idx.byID[id]; ds != nil {
+		return ds
+	}
+	return idx.bySlug[strings.ToLower(id)]
+}
+
+// setCatalogIndex replaces the catalog index by idx, whose datasets expire at
+// expiration.
+func (c *Client) setCatalogIndex(idx *catalogIndex, expiration time.Time) {
+	idx.indexSlugs()
+	idx.expiration = expiration
+	c.currentCatalogIndex.Store(idx)
+}
+
+// indexedDataset returns the dataset with the given ID or slug from the
+// catalog index, or nil if the index has expired or does not contain it. The
+// expiration of the index is noted in ctx.
+func (c *Client) indexedDataset(ctx context.Context, id string) *transformers.Dataset {
+	idx := c.currentCatalogIndex.Load()
+	if idx == nil || time.Now().After(idx.expiration) {
+		c.CountMiss("index")
+		return nil
+	}
+	ds := idx.lookup(id)
+	if ds != nil {
+		c.CountHit("index")
+		noteExpiration(ctx, idx.expiration)
+	} else {
+		c.CountMiss("index")
+	}
+	return ds
+}
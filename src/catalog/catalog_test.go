@@ -0,0 +1,442 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/config"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// stubUpstream serves a MetaData API of datasets ds-01, ds-02, ..., every
+// third of them deprecated, and counts the requests of each endpoint.
+type stubUpstream struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	datasets []transformers.Dataset
+	pages    atomic.Int64
+	details  atomic.Int64
+}
+
+func newStubUpstream(t *testing.T, n int) *stubUpstream {
+	t.Helper()
+	stub := &stubUpstream{}
+	for i := 1; i <= n; i++ {
+		stub.datasets = append(stub.datasets, transformers.Dataset{
+			ID:         datasetID(i),
+			Shortname:  fmt.Sprintf("Dataset %d", i),
+			ApiUrl:     "https://example.com/v1/Dataset" + strconv.Itoa(i),
+			Deprecated: i%3 == 0,
+			LastChange: "2024-05-01T08:00:00Z",
+		})
+	}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func datasetID(i int) string {
+	return fmt.Sprintf("ds-%02d", i)
+}
+
+func (s *stubUpstream) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if id, ok := strings.CutPrefix(r.URL.Path, "/v1/MetaData/"); ok {
+		s.details.Add(1)
+		for _, ds := range s.datasets {
+			if ds.ID == id {
+				json.NewEncoder(w).Encode(ds)
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+	s.pages.Add(1)
+	page, _ := strconv.Atoi(r.URL.Query().Get("pagenumber"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	start := min((page-1)*limit, len(s.datasets))
+	end := min(start+limit, len(s.datasets))
+	json.NewEncoder(w).Encode(MetaDataPage{
+		TotalResults: len(s.datasets),
+		TotalPages:   (len(s.datasets) + limit - 1) / limit,
+		CurrentPage:  page,
+		Items:        s.datasets[start:end],
+	})
+}
+
+// setLastChange changes the LastChange of the dataset with index i.
+func (s *stubUpstream) setLastChange(i int, lastChange string) {
+	s.mutex.Lock()
+	s.datasets[i-1].LastChange = lastChange
+	s.mutex.Unlock()
+}
+
+// stubTransport sends every request to the stub upstream instead of its host.
+type stubTransport struct {
+	target *url.URL
+}
+
+func (t stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.target.Scheme, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client of stub fetching upstream pages of 10
+// datasets, without prefetching or rate limit. configure, if not nil, adjusts
+// the configuration first.
+func newTestClient(t *testing.T, stub *stubUpstream, configure func(cfg *config.Config)) *Client {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Upstream.PageSize = 10
+	cfg.Upstream.PrefetchDepth = 0
+	cfg.Upstream.RPS = 0
+	if configure != nil {
+		configure(cfg)
+	}
+	c := New(cfg)
+	target, err := url.Parse(stub.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.upstreamClient = func() *http.Client {
+		return &http.Client{Transport: stubTransport{target}}
+	}
+	return c
+}
+
+func pageIDs(page *MetaDataPage) []string {
+	if page == nil {
+		return nil
+	}
+	ids := make([]string, len(page.Items))
+	for i, ds := range page.Items {
+		ids[i] = ds.ID
+	}
+	return ids
+}
+
+func datasetIDs(indexes ...int) []string {
+	ids := make([]string, len(indexes))
+	for i, index := range indexes {
+		ids[i] = datasetID(index)
+	}
+	return ids
+}
+
+func datasetRange(from, to int) []string {
+	var ids []string
+	for i := from; i <= to; i++ {
+		ids = append(ids, datasetID(i))
+	}
+	return ids
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name string
+		// pages are requested in order; the last one is checked.
+		pages      []int
+		pageSize   int
+		deprecated string
+		want       []string
+		wantTotal  int
+		// wantFetches is the number of upstream page requests.
+		wantFetches int64
+	}{
+		{
+			name:  "first page",
+			pages: []int{1}, pageSize: 10, deprecated: "include",
+			want: datasetRange(1, 10), wantTotal: 25, wantFetches: 1,
+		},
+		{
+			name:  "pages sliced from one upstream page",
+			pages: []int{1, 2}, pageSize: 5, deprecated: "include",
+			want: datasetRange(6, 10), wantTotal: 25, wantFetches: 1,
+		},
+		{
+			name:  "page across upstream pages",
+			pages: []int{1}, pageSize: 15, deprecated: "include",
+			want: datasetRange(1, 15), wantTotal: 25, wantFetches: 2,
+		},
+		{
+			name:  "last page short",
+			pages: []int{3}, pageSize: 10, deprecated: "include",
+			want: datasetRange(21, 25), wantTotal: 25, wantFetches: 1,
+		},
+		{
+			name:  "past the end",
+			pages: []int{4}, pageSize: 10, deprecated: "include",
+			want: nil, wantFetches: 1,
+		},
+		{
+			name:  "past the end of a cached catalog",
+			pages: []int{1, 4}, pageSize: 10, deprecated: "include",
+			want: nil, wantFetches: 1,
+		},
+		{
+			name:  "deprecated excluded",
+			pages: []int{1}, pageSize: 10, deprecated: "exclude",
+			want: datasetIDs(1, 2, 4, 5, 7, 8, 10, 11, 13, 14), wantTotal: 17, wantFetches: 3,
+		},
+		{
+			name:  "deprecated excluded, last page",
+			pages: []int{2}, pageSize: 10, deprecated: "exclude",
+			want: datasetIDs(16, 17, 19, 20, 22, 23, 25), wantTotal: 17, wantFetches: 3,
+		},
+		{
+			name:  "deprecated only",
+			pages: []int{1}, pageSize: 10, deprecated: "only",
+			want: datasetIDs(3, 6, 9, 12, 15, 18, 21, 24), wantTotal: 8, wantFetches: 3,
+		},
+		{
+			name:  "deprecated excluded, past the end",
+			pages: []int{3}, pageSize: 10, deprecated: "exclude",
+			want: nil, wantFetches: 3,
+		},
+		{
+			name:  "deprecated excluded, pages read from the cache",
+			pages: []int{1, 2}, pageSize: 10, deprecated: "exclude",
+			want: datasetIDs(16, 17, 19, 20, 22, 23, 25), wantTotal: 17, wantFetches: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubUpstream(t, 25)
+			c := newTestClient(t, stub, nil)
+			var got *MetaDataPage
+			for _, page := range tt.pages {
+				var err error
+				got, err = c.Page(context.Background(), page, tt.pageSize, nil, tt.deprecated)
+				if err != nil {
+					t.Fatalf("page %d: %v", page, err)
+				}
+			}
+			if ids := pageIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("got datasets %v, want %v", ids, tt.want)
+			}
+			if got != nil && got.TotalResults != tt.wantTotal {
+				t.Errorf("got total %d, want %d", got.TotalResults, tt.wantTotal)
+			}
+			if n := stub.pages.Load(); n != tt.wantFetches {
+				t.Errorf("got %d upstream page requests, want %d", n, tt.wantFetches)
+			}
+		})
+	}
+}
+
+// TestCatalogPageCounted checks that once the datasets kept by a policy are
+// counted, filtered pages only read the upstream pages up to the one
+// completing them, and pages past the end none but the first.
+func TestCatalogPageCounted(t *testing.T) {
+	tests := []struct {
+		name string
+		// removed are the upstream pages dropped from the cache after
+		// counting, which would be fetched again if read.
+		removed []int
+		page    int
+		want    []string
+		// wantTotal is checked unless want is nil.
+		wantTotal int
+	}{
+		{
+			name:    "first page",
+			removed: []int{3}, page: 1,
+			want: datasetIDs(1, 2, 4, 5, 7, 8, 10, 11, 13, 14), wantTotal: 17,
+		},
+		{
+			name:    "past the end",
+			removed: []int{2, 3}, page: 3,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubUpstream(t, 25)
+			c := newTestClient(t, stub, nil)
+			ctx := context.Background()
+			if _, err := c.Page(ctx, 1, 10, nil, "exclude"); err != nil {
+				t.Fatal(err)
+			}
+			c.datasetCache.remove(func(key pageKey) bool { return slices.Contains(tt.removed, key.page) })
+			got, err := c.Page(ctx, tt.page, 10, nil, "exclude")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := pageIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("got datasets %v, want %v", ids, tt.want)
+			}
+			if got != nil && got.TotalResults != tt.wantTotal {
+				t.Errorf("got total %d, want %d", got.TotalResults, tt.wantTotal)
+			}
+			if n := stub.pages.Load(); n != 3 {
+				t.Errorf("got %d upstream page requests, want 3", n)
+			}
+		})
+	}
+}
+
+// TestOverridesFetched checks that overrides are applied to fetched pages and
+// details, before the dataspaces of a request are filtered.
+func TestOverridesFetched(t *testing.T) {
+	stub := newStubUpstream(t, 5)
+	c := newTestClient(t, stub, nil)
+	c.datasetOverrides = map[string]map[string]interface{}{"ds-02": {"Dataspace": "mobility"}}
+	// Before the catalog is walked, details are fetched.
+	if ds := c.lookupDataset(context.Background(), "ds-02"); ds == nil || ds.Dataspace != "mobility" {
+		t.Errorf("got detail %+v, want dataspace mobility", ds)
+	}
+	ctx := WithDataspaces(context.Background(), Dataspaces{"mobility"})
+	all, err := c.AllDatasets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := pageIDs(&MetaDataPage{Items: all}); !slices.Equal(ids, datasetIDs(2)) {
+		t.Errorf("got datasets %v, want %v", ids, datasetIDs(2))
+	}
+}
+
+func TestDataset(t *testing.T) {
+	tests := []struct {
+		name string
+		// ids are looked up in order.
+		ids          []string
+		wantFound    []bool
+		wantRequests int64
+	}{
+		{"detail cached", []string{"ds-01", "ds-01"}, []bool{true, true}, 1},
+		{"details cached per ID", []string{"ds-01", "ds-02", "ds-01", "ds-02"}, []bool{true, true, true, true}, 2},
+		{"unknown ID remembered", []string{"missing", "missing"}, []bool{false, false}, 1},
+		{"unknown and known IDs", []string{"missing", "ds-03", "missing", "ds-03"}, []bool{false, true, false, true}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubUpstream(t, 5)
+			c := newTestClient(t, stub, nil)
+			for i, id := range tt.ids {
+				ds := c.Dataset(context.Background(), id)
+				if found := ds != nil; found != tt.wantFound[i] {
+					t.Fatalf("lookup %d of %s: found %v, want %v", i+1, id, found, tt.wantFound[i])
+				}
+				if ds != nil && ds.ID != id {
+					t.Fatalf("lookup %d of %s: got %s", i+1, id, ds.ID)
+				}
+			}
+			if n := stub.details.Load(); n != tt.wantRequests {
+				t.Errorf("got %d upstream detail requests, want %d", n, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestDatasetChangedOnPage(t *testing.T) {
+	stub := newStubUpstream(t, 5)
+	c := newTestClient(t, stub, nil)
+	ctx := context.Background()
+	c.Dataset(ctx, "ds-01")
+	stub.setLastChange(1, "2024-06-01T08:00:00Z")
+	if _, err := c.Page(ctx, 1, 10, nil, "include"); err != nil {
+		t.Fatal(err)
+	}
+	ds := c.Dataset(ctx, "ds-01")
+	if ds == nil || ds.LastChange != "2024-06-01T08:00:00Z" {
+		t.Fatalf("got %+v, want the changed dataset", ds)
+	}
+	if n := stub.details.Load(); n != 2 {
+		t.Errorf("got %d upstream detail requests, want 2", n)
+	}
+}
+
+func TestEnforceCacheBudget(t *testing.T) {
+	tests := []struct {
+		name          string
+		budget        int64
+		wantEvictions bool
+	}{
+		{"unlimited", 0, false},
+		{"within budget", 1 << 20, false},
+		{"over budget", 2000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newStubUpstream(t, 25)
+			c := newTestClient(t, stub, func(cfg *config.Config) {
+				cfg.Cache.MemoryBudget = tt.budget
+			})
+			ctx := context.Background()
+			for i := 1; i <= 25; i++ {
+				c.Dataset(ctx, datasetID(i))
+			}
+			var total int64
+			for _, bc := range c.budgetedCaches {
+				total += bc.bytes()
+			}
+			if tt.budget > 0 && total > tt.budget {
+				t.Errorf("caches hold %d bytes, over the budget of %d", total, tt.budget)
+			}
+			evictions := c.CacheStats()["details"].Evictions
+			if (evictions > 0) != tt.wantEvictions {
+				t.Errorf("got %d evictions, want evictions %v", evictions, tt.wantEvictions)
+			}
+		})
+	}
+}
+
+func TestEvictEarliest(t *testing.T) {
+	type entry struct {
+		size       int
+		expiration time.Time
+	}
+	now := time.Now()
+	entries := func() map[string]entry {
+		return map[string]entry{
+			"a": {100, now.Add(3 * time.Minute)},
+			"b": {200, now.Add(time.Minute)},
+			"c": {300, now.Add(2 * time.Minute)},
+		}
+	}
+	tests := []struct {
+		name      string
+		want      int64
+		wantFreed int64
+		wantKept  []string
+	}{
+		{"nothing", 0, 0, []string{"a", "b", "c"}},
+		{"earliest entry", 150, 200, []string{"a", "c"}},
+		{"until enough is freed", 201, 500, []string{"a"}},
+		{"everything", 1000, 600, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := entries()
+			freed, n := EvictEarliest(m, func(e entry) (int, time.Time) { return e.size, e.expiration }, tt.want)
+			if freed != tt.wantFreed {
+				t.Errorf("freed %d bytes, want %d", freed, tt.wantFreed)
+			}
+			if n != 3-len(tt.wantKept) {
+				t.Errorf("evicted %d entries, want %d", n, 3-len(tt.wantKept))
+			}
+			kept := slices.Sorted(maps.Keys(m))
+			if !slices.Equal(kept, tt.wantKept) {
+				t.Errorf("kept %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/time/rate"
+	"opendatahub.com/dataset-catalog-api/config"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// Client fetches the catalog from the upstream APIs and holds its caches. The
+// zero value is not usable; create clients with New. A process normally uses
+// one client, shared by all requests.
+type Client struct {
+	cfg *config.Config
+
+	// upstreamClient returns the HTTP client shared by all upstream requests,
+	// built by newUpstreamClient on first use.
+	upstreamClient func() *http.Client
+	// upstreamLimiter returns the token bucket shared by all upstream
+	// requests, built by newUpstreamLimiter on first use.
+	upstreamLimiter func() *rate.Limiter
+
+	accessToken           string
+	accessTokenExpiration time.Time
+	accessTokenMutex      sync.Mutex
+
+	// backoffUntil holds, per upstream host, the time until which no
+	// requests are sent to it.
+	backoffUntil      map[string]time.Time
+	backoffUntilMutex sync.Mutex
+
+	// primaryFailures counts the consecutive failed requests to the primary
+	// MetaData API; a success resets it.
+	primaryFailures int
+	failoverSince   time.Time
+	failoverUntil   time.Time
+	failoverMutex   sync.Mutex
+
+	lastSync      time.Time
+	lastFailure   time.Time
+	lastSyncMutex sync.RWMutex
+
+	// datasetCache is the page cache in use; in memory unless configured
+	// otherwise.
+	datasetCache pageCache
+	// refreshing marks pages with a background refresh in flight, guarded by
+	// cacheMutex.
+	refreshing map[pageKey]bool
+	cacheMutex sync.Mutex
+
+	// lastGood holds the most recent successful response of every page, kept
+	// past any TTL as the fallback for upstream failures, unless evicted to
+	// stay within CACHE_MEMORY_BUDGET.
+	lastGood      map[pageKey]lastGoodItem
+	lastGoodMutex sync.RWMutex
+
+	detailCache map[string]detailItem
+	detailMutex sync.RWMutex
+
+	notFoundCache map[string]time.Time
+	notFoundMutex sync.RWMutex
+
+	// documentCache memoizes the per-dataset documents, so a dataset that
+	// appears in listings, detail calls and dumps is transformed once per
+	// version. Entries are replaced when the dataset changes and expire like
+	// cached pages, so those of deleted datasets and of languages or
+	// publishers no longer requested are removed by the janitor.
+	documentCache map[documentKey]documentItem
+	documentMutex sync.RWMutex
+
+	openAPICache      map[string]openAPICacheItem
+	openAPICacheMutex sync.RWMutex
+
+	mobilityCache      []transformers.Dataset
+	mobilityExpiration time.Time
+	// mobilitySize is the estimated size of mobilityCache, counted against
+	// CACHE_MEMORY_BUDGET.
+	mobilitySize int
+	// mobilityFetch is closed when the fetch of the mobility datasets in
+	// flight, if any, is done.
+	mobilityFetch chan struct{}
+	mobilityMutex sync.Mutex
+
+	// currentCatalogIndex holds the index of the last complete walk of the
+	// catalog, by ForEachPage or a sync. Flushing the caches or evicting
+	// pages drops it.
+	currentCatalogIndex atomic.Pointer[catalogIndex]
+
+	// recentPages holds when each page was last requested.
+	recentPages map[recentPage]time.Time
+	// prefetching holds a channel, closed when done, for every prefetch in
+	// flight, and prefetched when each page prefetched but not read yet was
+	// stored.
+	prefetching   map[pageKey]chan struct{}
+	prefetched    map[pageKey]time.Time
+	prefetchMutex sync.Mutex
+
+	// persistentCache is the optional on-disk copy of the upstream responses,
+	// set by OpenPersistentCache. It lets the catalog survive restarts and
+	// keeps it available while the upstream MetaData API is down.
+	persistentCache *bolt.DB
+
+	// snapshot is the catalog loaded by LoadSnapshot, indexed in
+	// snapshotIndex. Both are set by setSnapshot before the server starts and
+	// only read afterwards.
+	snapshot      []transformers.Dataset
+	snapshotIndex *catalogIndex
+
+	// datasetOverrides are the patches loaded by LoadDatasetOverrides, by
+	// dataset ID, each a map from upstream field name to its value.
+	datasetOverrides map[string]map[string]interface{}
+
+	catalogSync      SyncStatus
+	catalogSyncMutex sync.Mutex
+	syncScheduler    *cron.Cron
+	// syncHooks are called after every successful sync.
+	syncHooks []func(started time.Time, changed bool)
+	// syncDigests holds the content digest of every page of the last
+	// successful sync, in page order, followed by that of the mobility
+	// datasets.
+	syncDigests      [][sha256.Size]byte
+	syncDigestsMutex sync.Mutex
+
+	// instanceID identifies this client on the invalidation channel.
+	instanceID          string
+	invalidationClient  *redis.Client
+	invalidationChannel string
+
+	// cacheCounts holds the counters of every cache by name. The map itself
+	// is only modified by RegisterCache.
+	cacheCounts map[string]*cacheCounters
+	// registeredCaches are the caches added by RegisterCache.
+	registeredCaches []Cache
+	// budgetedCaches lists the caches held against CACHE_MEMORY_BUDGET in
+	// eviction order, see newBudgetedCaches.
+	budgetedCaches []budgetedCache
+	// budgetMutex serializes the enforcement of the budget.
+	budgetMutex sync.Mutex
+
+	// driftReported holds the kind and field of every drift already logged.
+	driftReported      map[string]bool
+	driftReportedMutex sync.Mutex
+	upstreamDrift      *prometheus.CounterVec
+
+	upstreamMetrics
+	backoffMetrics
+	prefetchMetrics
+}
+
+// New returns a client of the catalog configured by cfg. Nothing is fetched
+// until the client is used: the upstream HTTP client and the rate limiter are
+// built on first use.
+func New(cfg *config.Config) *Client {
+	c := &Client{
+		cfg:           cfg,
+		backoffUntil:  make(map[string]time.Time),
+		datasetCache:  newMemoryPageCache(),
+		refreshing:    make(map[pageKey]bool),
+		lastGood:      make(map[pageKey]lastGoodItem),
+		detailCache:   make(map[string]detailItem),
+		notFoundCache: make(map[string]time.Time),
+		documentCache: make(map[documentKey]documentItem),
+		openAPICache:  make(map[string]openAPICacheItem),
+		recentPages:   make(map[recentPage]time.Time),
+		prefetching:   make(map[pageKey]chan struct{}),
+		prefetched:    make(map[pageKey]time.Time),
+		instanceID:    newInstanceID(),
+		cacheCounts: map[string]*cacheCounters{
+			"pages":     {},
+			"details":   {},
+			"notFound":  {},
+			"openapi":   {},
+			"documents": {},
+			"index":     {},
+			"mobility":  {},
+			"lastGood":  {},
+		},
+		driftReported:   make(map[string]bool),
+		upstreamDrift:   newDriftCounter(),
+		upstreamMetrics: newUpstreamMetrics(),
+		backoffMetrics:  newBackoffMetrics(),
+		prefetchMetrics: newPrefetchMetrics(),
+	}
+	c.upstreamClient = sync.OnceValue(c.newUpstreamClient)
+	c.upstreamLimiter = sync.OnceValue(c.newUpstreamLimiter)
+	c.budgetedCaches = c.newBudgetedCaches()
+	return c
+}
+
+// Collectors returns the Prometheus metrics of the client: the cache
+// statistics, the memory budget and the upstream requests. Register them with
+// the registry the metrics are served from.
+func (c *Client) Collectors() []prometheus.Collector {
+	collectors := []prometheus.Collector{cacheCollector{c}, c.budgetGauge(), c.upstreamDrift}
+	collectors = append(collectors, c.upstreamMetrics.collectors()...)
+	collectors = append(collectors, c.backoffCollectors()...)
+	return append(collectors, c.prefetchMetrics.collectors()...)
+}
@@ -0,0 +1,130 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// LoadDatasetOverrides loads the dataset overrides from the YAML or JSON file
+// at path, a map from dataset ID to the upstream fields to patch, named as in
+// the MetaData API:
+//
+//	fixture-weather:
+//	  ApiDescription:
+//	    de: Wettervorhersagen des Landeswetterdienstes.
+//	  LicenseInfo:
+//	    License: CC0
+//	  Category: [Weather, Forecast]
+//
+// Objects are merged field by field into the upstream ones, so an override
+// can add a translation without repeating the others; any other value,
+// including a list, replaces the upstream one. Unknown fields and values of
+// the wrong type are rejected.
+func (c *Client) LoadDatasetOverrides(path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var loaded map[string]map[string]interface{}
+	if err := yaml.Unmarshal(body, &loaded); err != nil {
+		return err
+	}
+	for id, patch := range loaded {
+		if _, ok := patch["Id"]; ok {
+			return fmt.Errorf("dataset %s: Id cannot be overridden", id)
+		}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return fmt.Errorf("dataset %s: %w", id, err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&transformers.Dataset{}); err != nil {
+			return fmt.Errorf("dataset %s: %w", id, err)
+		}
+	}
+	c.datasetOverrides = loaded
+	log.Printf("Loaded overrides for %d datasets from %s", len(loaded), path)
+	return nil
+}
+
+// applyOverrides returns datasets with their overrides applied. They are
+// applied once, as pages, details and the mobility datasets are fetched or
+// loaded, so the caches, the catalog index and every listing and dataspace
+// filter see the patched datasets. Applying them again changes nothing.
+// datasets are copied only if one of them has an override; otherwise datasets
+// itself is returned.
+func (c *Client) applyOverrides(datasets []transformers.Dataset) []transformers.Dataset {
+	var out []transformers.Dataset
+	for i, ds := range datasets {
+		if _, ok := c.datasetOverrides[ds.ID]; !ok {
+			continue
+		}
+		if out == nil {
+			out = slices.Clone(datasets)
+		}
+		out[i] = c.applyOverride(ds)
+	}
+	if out == nil {
+		return datasets
+	}
+	return out
+}
+
+// applyOverride returns ds patched with its override, if any. If the patch
+// cannot be applied, ds is returned unchanged.
+func (c *Client) applyOverride(ds transformers.Dataset) transformers.Dataset {
+	patch, ok := c.datasetOverrides[ds.ID]
+	if !ok {
+		return ds
+	}
+	data, err := json.Marshal(ds)
+	if err != nil {
+		log.Printf("Error applying override to %s: %v", ds.ID, err)
+		return ds
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		log.Printf("Error applying override to %s: %v", ds.ID, err)
+		return ds
+	}
+	for k, v := range patch {
+		fields[k] = mergeOverride(fields[k], v)
+	}
+	if data, err = json.Marshal(fields); err == nil {
+		var patched transformers.Dataset
+		if err = json.Unmarshal(data, &patched); err == nil {
+			return patched
+		}
+	}
+	log.Printf("Error applying override to %s: %v", ds.ID, err)
+	return ds
+}
+
+// mergeOverride returns override merged into base: objects are merged field
+// by field, any other override replaces base.
+func mergeOverride(base, override interface{}) interface{} {
+	b, ok := base.(map[string]interface{})
+	o, ok2 := override.(map[string]interface{})
+	if !ok || !ok2 {
+		return override
+	}
+	merged := make(map[string]interface{}, len(b)+len(o))
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range o {
+		merged[k] = mergeOverride(merged[k], v)
+	}
+	return merged
+}
@@ -0,0 +1,61 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"strings"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// Dataspaces limits the datasets of the catalog to some dataspaces, matched
+// case-insensitively. Empty Dataspaces do not limit them.
+type Dataspaces []string
+
+type dataspacesContextKey struct{}
+
+// WithDataspaces returns a copy of ctx limiting the datasets listed, walked
+// and looked up with it to dataspaces.
+func WithDataspaces(ctx context.Context, dataspaces Dataspaces) context.Context {
+	return context.WithValue(ctx, dataspacesContextKey{}, dataspaces)
+}
+
+// dataspacesFrom returns the dataspaces ctx is limited to, or nil for all.
+func dataspacesFrom(ctx context.Context) Dataspaces {
+	dataspaces, _ := ctx.Value(dataspacesContextKey{}).(Dataspaces)
+	return dataspaces
+}
+
+// Restricted reports whether d limits the catalog to some dataspaces.
+func (d Dataspaces) Restricted() bool {
+	return len(d) > 0
+}
+
+// Includes reports whether ds belongs to the dataspaces of d.
+func (d Dataspaces) Includes(ds transformers.Dataset) bool {
+	if !d.Restricted() {
+		return true
+	}
+	for _, v := range d {
+		if strings.EqualFold(v, ds.Dataspace) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the datasets of items that belong to the dataspaces of d.
+func (d Dataspaces) Filter(items []transformers.Dataset) []transformers.Dataset {
+	if !d.Restricted() {
+		return items
+	}
+	var out []transformers.Dataset
+	for _, ds := range items {
+		if d.Includes(ds) {
+			out = append(out, ds)
+		}
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package catalog fetches the datasets of the Open Data Hub MetaData API and
+// the mobility API and keeps them cached: pages and details, with
+// last-known-good copies, failover to a fallback URL, a persistent cache,
+// scheduled syncs and an offline mode serving fixtures.
+//
+// It has no dependency on the HTTP server. A Client holds the caches and is
+// configured by the config.Config it is created with, the same configuration
+// as the service's:
+//
+//	cfg := config.Default()
+//	client := catalog.New(cfg)
+//	datasets, err := client.AllDatasets(ctx)
+//	doc := transformers.ToDCAT(cfg.Publisher, datasets, "en")
+//
+// Other packages can add caches of their own with Client.RegisterCache, so
+// they are flushed and reported along with those of the catalog. The metrics
+// of a client are returned by Client.Collectors, for the caller to register.
+package catalog
@@ -0,0 +1,103 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"fmt"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// documentKey identifies a transformed document: its kind (dcat, odps30 or
+// odps31), the dataset, the requested language and, for the ODPS documents,
+// which include the publisher's contact data, the publisher.
+type documentKey struct {
+	kind      string
+	id        string
+	lang      string
+	publisher string
+}
+
+type documentItem struct {
+	// lastChange is the LastChange of the dataset the document was built from.
+	lastChange string
+	doc        interface{}
+	expiration time.Time
+	// size is the estimated size of doc, counted against CACHE_MEMORY_BUDGET.
+	size int
+}
+
+// memoDocument returns the document of ds stored under key if it was built
+// from the same version of ds and has not expired, and otherwise builds and
+// stores it. Datasets without ID or LastChange are not memoized. Memoized
+// documents are shared by concurrent requests and must not be modified.
+func memoDocument[T any](c *Client, key documentKey, ds transformers.Dataset, build func() T) T {
+	if ds.ID == "" || ds.LastChange == "" {
+		return build()
+	}
+	c.documentMutex.RLock()
+	item, found := c.documentCache[key]
+	c.documentMutex.RUnlock()
+	if found && item.lastChange == ds.LastChange && time.Now().Before(item.expiration) {
+		c.CountHit("documents")
+		return item.doc.(T)
+	}
+	c.CountMiss("documents")
+	doc := build()
+	item = documentItem{lastChange: ds.LastChange, doc: doc, expiration: time.Now().Add(jitteredTTL()), size: encodedSize(doc)}
+	c.documentMutex.Lock()
+	c.documentCache[key] = item
+	c.documentMutex.Unlock()
+	c.EnforceCacheBudget()
+	return doc
+}
+
+// publisherKey identifies p in document keys. The licenses and ODPS defaults
+// are left out, as every publisher profile shares them.
+func publisherKey(p transformers.Publisher) string {
+	p.Licenses, p.ODPSDefaults = nil, transformers.ODPSDefaults{}
+	return fmt.Sprintf("%v", p)
+}
+
+// DCATDatasets returns the memoized DCAT dataset nodes of datasets. They only
+// depend on the licenses of p, which every publisher profile shares, so they
+// are memoized for all profiles.
+func (c *Client) DCATDatasets(p transformers.Publisher, datasets []transformers.Dataset, lang string) []transformers.DCATDataset {
+	return transformers.MapDatasets(datasets, func(ds transformers.Dataset) transformers.DCATDataset {
+		return memoDocument(c, documentKey{kind: "dcat", id: ds.ID, lang: lang}, ds, func() transformers.DCATDataset {
+			return transformers.ToDCATDataset(p, ds, lang)
+		})
+	})
+}
+
+// DCATCatalog is transformers.ToDCAT with memoized dataset nodes.
+func (c *Client) DCATCatalog(p transformers.Publisher, datasets []transformers.Dataset, lang string) *transformers.Catalog {
+	catalog := transformers.DCATCatalog(p)
+	catalog.Datasets = c.DCATDatasets(p, datasets, lang)
+	return catalog
+}
+
+// ODPS30Document returns the memoized ODPS v3.0 document of ds.
+func (c *Client) ODPS30Document(p transformers.Publisher, ds transformers.Dataset, lang string) *transformers.ODPS30Document {
+	key := documentKey{kind: "odps30", id: ds.ID, lang: lang, publisher: publisherKey(p)}
+	return memoDocument(c, key, ds, func() *transformers.ODPS30Document {
+		return transformers.ToODPS30(p, []transformers.Dataset{ds}, lang)
+	})
+}
+
+// ODPS31Documents returns the memoized ODPS v3.1 documents of datasets.
+func (c *Client) ODPS31Documents(p transformers.Publisher, datasets []transformers.Dataset, lang string) []*transformers.ODPS31Document {
+	pk := publisherKey(p)
+	return transformers.MapDatasets(datasets, func(ds transformers.Dataset) *transformers.ODPS31Document {
+		return memoDocument(c, documentKey{kind: "odps31", id: ds.ID, lang: lang, publisher: pk}, ds, func() *transformers.ODPS31Document {
+			return transformers.ToODPS31(p, []transformers.Dataset{ds}, lang)
+		})
+	})
+}
+
+// ODPS31Document returns the memoized ODPS v3.1 document of ds.
+func (c *Client) ODPS31Document(p transformers.Publisher, ds transformers.Dataset, lang string) *transformers.ODPS31Document {
+	return c.ODPS31Documents(p, []transformers.Dataset{ds}, lang)[0]
+}
@@ -0,0 +1,128 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// Export aggregates the datasets of the whole upstream catalog in the
+// background, for exports like the ODPS 3.1 dump. Pages are walked with
+// walkPages and added in page order, followed by the datasets of the mobility
+// API as in ForEachPage, and the progress is kept when an upstream call fails,
+// so the next run resumes from the page it stopped at instead of starting
+// over.
+type Export struct {
+	client *Client
+	mu     sync.Mutex
+	ttl    time.Duration
+
+	// Last completed export.
+	datasets    []transformers.Dataset
+	generatedAt time.Time
+	// expired forces regeneration before ttl has passed.
+	expired bool
+
+	// Export under construction. next is the upstream page to continue
+	// with, the zero key for the first one.
+	building   []transformers.Dataset
+	next       pageKey
+	pagesDone  int
+	totalPages int
+	running    bool
+}
+
+// NewExport returns an export of the catalog of c that is regenerated once it
+// is older than ttl.
+func (c *Client) NewExport(ttl time.Duration) *Export {
+	return &Export{client: c, ttl: ttl}
+}
+
+// Snapshot returns the last completed export, starting a background
+// generation when there is none or it is older than its ttl.
+func (d *Export) Snapshot() ([]transformers.Dataset, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ready := !d.generatedAt.IsZero()
+	if (!ready || d.expired || time.Since(d.generatedAt) > d.ttl) && !d.running {
+		d.running = true
+		go d.generate()
+	}
+	return d.datasets, ready
+}
+
+// Expire makes the next Snapshot regenerate the export. The current export is
+// served until the new one is complete.
+func (d *Export) Expire() {
+	d.mu.Lock()
+	d.expired = true
+	d.mu.Unlock()
+}
+
+// Progress reports the number of processed and total upstream pages of the running generation.
+func (d *Export) Progress() (int, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pagesDone, d.totalPages
+}
+
+func (d *Export) generate() {
+	defer func() {
+		d.mu.Lock()
+		d.running = false
+		d.mu.Unlock()
+	}()
+
+	d.mu.Lock()
+	start := d.next
+	d.mu.Unlock()
+	if start == (pageKey{}) {
+		start = newPageKey(1, d.client.upstreamPageSize(), nil)
+	}
+
+	err := d.client.walkPages(context.Background(), start, d.client.fetchDatasets, d.add)
+	if err != nil {
+		d.mu.Lock()
+		if d.next == (pageKey{}) {
+			d.next = start
+		}
+		log.Printf("Export paused at page %d: %v", d.next.page, err)
+		d.mu.Unlock()
+		return
+	}
+
+	mobility := d.client.mobilityDatasets(context.Background())
+	d.mu.Lock()
+	d.datasets = append(d.building, mobility...)
+	d.generatedAt = time.Now()
+	d.expired = false
+	d.building = nil
+	d.next = pageKey{}
+	d.pagesDone = 0
+	d.mu.Unlock()
+}
+
+// add appends the datasets of an upstream page to the export under
+// construction and records the page as processed.
+func (d *Export) add(key pageKey, data *MetaDataPage) error {
+	next, ok, err := d.client.nextPageKey(key, data)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.totalPages = data.TotalPages
+	d.building = append(d.building, data.Items...)
+	d.pagesDone++
+	d.next = pageKey{}
+	if ok {
+		d.next = next
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type freshnessContextKey struct{}
+
+// freshness records the earliest expiration of the cached pages, details and
+// catalog index read on behalf of a request.
+type freshness struct {
+	mu         sync.Mutex
+	expiration time.Time
+}
+
+// WithFreshness returns a context that records when the cached data read with
+// it expires, as reported by DataExpiration. Response caches use it so they do
+// not keep a response past the data it was built from.
+func WithFreshness(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshnessContextKey{}, &freshness{})
+}
+
+// DataExpiration returns the earliest expiration of the cached data read with
+// ctx, a context returned by WithFreshness, or false if none was read. Data
+// served past its expiration, such as last-known-good pages, counts as
+// expiring when it was read.
+func DataExpiration(ctx context.Context) (time.Time, bool) {
+	f, ok := ctx.Value(freshnessContextKey{}).(*freshness)
+	if !ok {
+		return time.Time{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.expiration, !f.expiration.IsZero()
+}
+
+// noteExpiration records in ctx that data expiring at expiration was read.
+func noteExpiration(ctx context.Context, expiration time.Time) {
+	f, ok := ctx.Value(freshnessContextKey{}).(*freshness)
+	if !ok || expiration.IsZero() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.expiration.IsZero() || expiration.Before(f.expiration) {
+		f.expiration = expiration
+	}
+}
@@ -0,0 +1,56 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ProbeUpstream fetches a one-item page of the MetaData API, bypassing the caches.
+func (c *Client) ProbeUpstream(ctx context.Context) error {
+	resp, err := c.upstreamGet(ctx, endpointHealth, newPageKey(1, 1, nil).url())
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// CacheBackend returns the name of the page cache backend.
+func (c *Client) CacheBackend() string {
+	return c.datasetCache.name()
+}
+
+// ProbeCache checks that the page cache backend is reachable.
+func (c *Client) ProbeCache(ctx context.Context) error {
+	return c.datasetCache.ping()
+}
+
+// PersistentCacheEnabled reports whether OpenPersistentCache opened a cache file.
+func (c *Client) PersistentCacheEnabled() bool {
+	return c.persistentCache != nil
+}
+
+// ProbePersistentCache checks that the persistent cache file is readable.
+func (c *Client) ProbePersistentCache(ctx context.Context) error {
+	return c.persistentCache.View(func(tx *bolt.Tx) error { return nil })
+}
+
+// InvalidationEnabled reports whether StartInvalidation connected to Redis.
+func (c *Client) InvalidationEnabled() bool {
+	return c.invalidationClient != nil
+}
+
+// ProbeInvalidation checks that the Redis server of the invalidation channel
+// is reachable.
+func (c *Client) ProbeInvalidation(ctx context.Context) error {
+	return c.invalidationClient.Ping(ctx).Err()
+}
@@ -0,0 +1,82 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// invalidationMessage asks every instance to flush cached data, with the same
+// scope as Flush.
+type invalidationMessage struct {
+	// Origin is the instance that sent the message, which ignores it.
+	Origin string `json:"origin"`
+	Page   int    `json:"page,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// StartInvalidation connects to the Redis server at redisURL and subscribes to
+// channel, so that admin flushes and detected upstream changes invalidate the
+// local caches of all instances, not just the one that noticed them.
+func (c *Client) StartInvalidation(redisURL, channel string) error {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return err
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub := client.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed, which also checks the connection.
+	if _, err := sub.Receive(ctx); err != nil {
+		client.Close()
+		return err
+	}
+	c.invalidationClient = client
+	c.invalidationChannel = channel
+
+	go func() {
+		for msg := range sub.Channel() {
+			var m invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				log.Printf("Ignoring invalid invalidation message: %v", err)
+				continue
+			}
+			if m.Origin == c.instanceID {
+				continue
+			}
+			flushed := c.Flush(m.Page, m.ID)
+			log.Printf("Flushed caches on request of instance %s (page %d, id %q): %v", m.Origin, m.Page, m.ID, flushed)
+		}
+	}()
+	return nil
+}
+
+// PublishInvalidation asks the other instances to flush the cached data of
+// upstream page or dataset id (everything if neither is given). It does
+// nothing unless StartInvalidation was called.
+func (c *Client) PublishInvalidation(page int, id string) {
+	if c.invalidationClient == nil {
+		return
+	}
+	payload, _ := json.Marshal(invalidationMessage{Origin: c.instanceID, Page: page, ID: id})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.invalidationClient.Publish(ctx, c.invalidationChannel, payload).Err(); err != nil {
+		log.Printf("Error publishing cache invalidation: %v", err)
+	}
+}
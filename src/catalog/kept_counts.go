@@ -0,0 +1,78 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"strings"
+	"sync"
+)
+
+// keptCounts is the kept-count index of a cached upstream page: the number of
+// its datasets each listing policy keeps. With it, catalogPage skips the pages
+// before and after the requested page without filtering them again. The index
+// of the first upstream page also holds the number of datasets each policy
+// keeps in the whole catalog, so catalogPage reads no further than the
+// requested page once it is known. It lives as long as the cached page, so it
+// never outlasts the datasets it counts.
+type keptCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+	totals map[string]int
+}
+
+// listingPolicy returns the key of the datasets kept by the dataspaces and the
+// ?deprecated= policy deprecated in keptCounts.
+func listingPolicy(dataspaces Dataspaces, deprecated string) string {
+	return deprecated + "|" + strings.ToLower(strings.Join(dataspaces, ","))
+}
+
+// get returns the number of datasets kept by policy, if counted. A nil index,
+// as for pages not held in memory, counts nothing.
+func (k *keptCounts) get(policy string) (int, bool) {
+	if k == nil {
+		return 0, false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	n, ok := k.counts[policy]
+	return n, ok
+}
+
+// set records that policy keeps n datasets of the page.
+func (k *keptCounts) set(policy string, n int) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.counts == nil {
+		k.counts = make(map[string]int)
+	}
+	k.counts[policy] = n
+}
+
+// total returns the number of datasets kept by policy in the whole catalog,
+// if counted.
+func (k *keptCounts) total(policy string) (int, bool) {
+	if k == nil {
+		return 0, false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	n, ok := k.totals[policy]
+	return n, ok
+}
+
+// setTotal records that policy keeps n datasets of the whole catalog.
+func (k *keptCounts) setTotal(policy string, n int) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.totals == nil {
+		k.totals = make(map[string]int)
+	}
+	k.totals[policy] = n
+}
@@ -0,0 +1,133 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// memcachedPageCache stores pages in memcached, so several instances share
+// them. Memcached cannot list its keys, so removal and statistics only cover
+// the pages this instance has stored.
+type memcachedPageCache struct {
+	client *memcache.Client
+	// maxStaleness is CACHE_MAX_STALENESS, how long entries are kept past
+	// their expiration.
+	maxStaleness time.Duration
+
+	mu   sync.Mutex
+	keys map[pageKey]time.Time
+}
+
+// memcachedItem is the stored form of a cacheItem.
+type memcachedItem struct {
+	Items        []transformers.Dataset `json:"items"`
+	TotalResults int                    `json:"totalResults"`
+	NextPage     string                 `json:"nextPage,omitempty"`
+	Expiration   time.Time              `json:"expiration"`
+}
+
+func newMemcachedPageCache(servers []string, maxStaleness time.Duration) (*memcachedPageCache, error) {
+	client := memcache.New(servers...)
+	if err := client.Ping(); err != nil {
+		return nil, err
+	}
+	return &memcachedPageCache{client: client, maxStaleness: maxStaleness, keys: make(map[pageKey]time.Time)}, nil
+}
+
+// memcachedKey maps a page key to a memcached key, which may not exceed 250
+// bytes or contain spaces.
+func memcachedKey(key pageKey) string {
+	sum := sha256.Sum256([]byte(key.url()))
+	return "catalog:page:" + hex.EncodeToString(sum[:])
+}
+
+func (m *memcachedPageCache) name() string { return "memcached" }
+
+func (m *memcachedPageCache) ping() error { return m.client.Ping() }
+
+func (m *memcachedPageCache) get(key pageKey) (cacheItem, bool) {
+	it, err := m.client.Get(memcachedKey(key))
+	if err != nil {
+		if err != memcache.ErrCacheMiss {
+			log.Printf("Error reading page %d from memcached: %v", key.page, err)
+		}
+		return cacheItem{}, false
+	}
+	var stored memcachedItem
+	if err := json.Unmarshal(it.Value, &stored); err != nil {
+		return cacheItem{}, false
+	}
+	return cacheItem{data: stored.Items, totalResults: stored.TotalResults, nextPage: stored.NextPage, expiration: stored.Expiration}, true
+}
+
+func (m *memcachedPageCache) set(key pageKey, item cacheItem) {
+	value, err := json.Marshal(memcachedItem{Items: item.data, TotalResults: item.totalResults, NextPage: item.nextPage, Expiration: item.expiration})
+	if err != nil {
+		return
+	}
+	// Keep the entry for as long as it may be served stale.
+	ttl := time.Until(item.expiration) + m.maxStaleness
+	err = m.client.Set(&memcache.Item{
+		Key:        memcachedKey(key),
+		Value:      value,
+		Expiration: int32(ttl.Seconds()),
+	})
+	if err != nil {
+		log.Printf("Error writing page %d to memcached: %v", key.page, err)
+		return
+	}
+	m.mu.Lock()
+	m.keys[key] = item.expiration
+	m.mu.Unlock()
+}
+
+func (m *memcachedPageCache) remove(match func(pageKey) bool) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key := range m.keys {
+		if !match(key) {
+			continue
+		}
+		delete(m.keys, key)
+		if err := m.client.Delete(memcachedKey(key)); err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// removeExpired forgets the keys of entries that expired before cutoff;
+// memcached drops the entries themselves once their TTL has passed.
+func (m *memcachedPageCache) removeExpired(cutoff time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key, expiration := range m.keys {
+		if expiration.Before(cutoff) {
+			delete(m.keys, key)
+			n++
+		}
+	}
+	return n
+}
+
+func (m *memcachedPageCache) expirations() []time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]time.Time, 0, len(m.keys))
+	for _, expiration := range m.keys {
+		out = append(out, expiration)
+	}
+	return out
+}
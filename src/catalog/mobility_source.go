@@ -0,0 +1,170 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// mobilityIDPrefix marks the IDs of datasets built from the mobility API, so
+// they never collide with MetaData IDs.
+const mobilityIDPrefix = "mobility-"
+
+// mobilityStationType is a station type of the Open Data Hub mobility (ninja) API.
+type mobilityStationType struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// mobilityDataType is a data type measured by the stations of a station type.
+type mobilityDataType struct {
+	Name        string `json:"tname"`
+	Description string `json:"tdescription"`
+	Unit        string `json:"tunit"`
+}
+
+// mobilityDatasets returns one dataset per station type of the mobility API at
+// MOBILITY_API_URL (e.g. https://mobility.api.opendatahub.com/v2), or nil if
+// it is not set or in offline mode. The datasets are cached for 5 minutes;
+// when the mobility API fails, the previous ones are kept, so it never breaks
+// the tourism catalog. Concurrent callers wait for a single fetch, which is
+// not cancelled with the ctx of the caller that started it.
+func (c *Client) mobilityDatasets(ctx context.Context) []transformers.Dataset {
+	base := c.cfg.Upstream.MobilityAPIURL
+	if base == "" || c.OfflineMode() {
+		return nil
+	}
+	c.mobilityMutex.Lock()
+	cached, done := c.mobilityCache, c.mobilityFetch
+	if time.Now().Before(c.mobilityExpiration) {
+		c.mobilityMutex.Unlock()
+		c.CountHit("mobility")
+		return cached
+	}
+	c.CountMiss("mobility")
+	if done != nil {
+		c.mobilityMutex.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return cached
+		}
+		c.mobilityMutex.Lock()
+		defer c.mobilityMutex.Unlock()
+		return c.mobilityCache
+	}
+	done = make(chan struct{})
+	c.mobilityFetch = done
+	c.mobilityMutex.Unlock()
+
+	datasets, err := c.fetchMobilityDatasets(context.WithoutCancel(ctx), base)
+	c.mobilityMutex.Lock()
+	c.mobilityFetch = nil
+	close(done)
+	if err != nil {
+		cached = c.mobilityCache
+		c.mobilityMutex.Unlock()
+		log.Printf("Error fetching mobility station types: %v", err)
+		return cached
+	}
+	datasets = c.applyOverrides(datasets)
+	c.mobilityCache = datasets
+	c.mobilitySize = encodedSize(datasets)
+	c.mobilityExpiration = time.Now().Add(jitteredTTL())
+	c.mobilityMutex.Unlock()
+	c.EnforceCacheBudget()
+	return datasets
+}
+
+// mobilityDataset returns the mobility dataset with the given ID, or nil.
+func (c *Client) mobilityDataset(ctx context.Context, id string) *transformers.Dataset {
+	for _, ds := range c.mobilityDatasets(ctx) {
+		if ds.ID == id {
+			return &ds
+		}
+	}
+	return nil
+}
+
+func (c *Client) fetchMobilityDatasets(ctx context.Context, base string) ([]transformers.Dataset, error) {
+	var stationTypes []mobilityStationType
+	if err := c.getMobility(ctx, base+"/flat", &stationTypes); err != nil {
+		return nil, err
+	}
+	var datasets []transformers.Dataset
+	for _, st := range stationTypes {
+		var dataTypes []mobilityDataType
+		typeURL := base + "/flat/" + url.PathEscape(st.ID)
+		q := url.Values{"select": {"tname,tdescription,tunit"}, "distinct": {"true"}, "limit": {"-1"}}
+		if err := c.getMobility(ctx, typeURL+"/*?"+q.Encode(), &dataTypes); err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, mobilityToDataset(base, typeURL, st, dataTypes))
+	}
+	return datasets, nil
+}
+
+// getMobility decodes a mobility API response into v. Lists come either bare
+// or wrapped in a "data" envelope, depending on the endpoint.
+func (c *Client) getMobility(ctx context.Context, url string, v interface{}) error {
+	resp, err := c.upstreamGet(ctx, endpointMobility, url)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Data != nil {
+		body = envelope.Data
+	}
+	return json.Unmarshal(body, v)
+}
+
+// mobilityToDataset describes a station type in the shape of a MetaData
+// entry, in the "mobility" dataspace.
+func mobilityToDataset(base, typeURL string, st mobilityStationType, dataTypes []mobilityDataType) transformers.Dataset {
+	description := st.Description
+	if description == "" {
+		description = "Stations of type " + st.ID + " and their measurements from the Open Data Hub mobility API."
+	}
+	var names []string
+	for _, dt := range dataTypes {
+		name := dt.Name
+		if dt.Unit != "" {
+			name += " (" + dt.Unit + ")"
+		}
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		description += " Data types: " + strings.Join(names, ", ") + "."
+	}
+	return transformers.Dataset{
+		ID:             mobilityIDPrefix + st.ID,
+		Self:           typeURL,
+		Type:           "mobility",
+		ApiUrl:         typeURL,
+		ApiType:        "timeseries",
+		BaseUrl:        base,
+		Dataspace:      "mobility",
+		Shortname:      st.ID,
+		ApiDescription: map[string]string{transformers.DefaultLanguage: description},
+	}
+}
@@ -0,0 +1,89 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// errOffline is returned for upstream requests in offline mode.
+var errOffline = errors.New("offline mode: upstream requests are disabled")
+
+// bundledFixtures is the catalog served in offline mode unless
+// OFFLINE_FIXTURES points to other fixtures.
+//
+//go:embed fixtures/*.json
+var bundledFixtures embed.FS
+
+// OfflineMode reports whether OFFLINE_MODE is set. In offline mode no upstream
+// request is sent: the catalog is served from the fixtures loaded by
+// LoadFixtures, so the full API runs without internet access.
+func (c *Client) OfflineMode() bool {
+	return c.cfg.Offline
+}
+
+// LoadFixtures loads the catalog served in offline mode from path, a fixture
+// file or a directory of .json and .ndjson fixture files, or from the bundled
+// fixtures if path is empty. Fixtures are in any format LoadSnapshot accepts.
+func (c *Client) LoadFixtures(path string) error {
+	fsys, files, err := fixtureFiles(path)
+	if err != nil {
+		return err
+	}
+	var datasets []transformers.Dataset
+	for _, name := range files {
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseSnapshot(body)
+		if err != nil {
+			return fmt.Errorf("parsing fixture %s: %w", name, err)
+		}
+		datasets = append(datasets, parsed...)
+	}
+	if len(datasets) == 0 {
+		return errors.New("fixtures contain no datasets")
+	}
+	c.setSnapshot(datasets)
+	log.Printf("Offline mode: serving %d datasets from %d fixture files", len(datasets), len(files))
+	return nil
+}
+
+// fixtureFiles lists the fixture files at path, in name order.
+func fixtureFiles(path string) (fs.FS, []string, error) {
+	if path == "" {
+		files, err := fs.Glob(bundledFixtures, "fixtures/*.json")
+		return bundledFixtures, files, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.IsDir() {
+		return os.DirFS(filepath.Dir(path)), []string{filepath.Base(path)}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && (strings.HasSuffix(e.Name(), ".json") || strings.HasSuffix(e.Name(), ".ndjson")) {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	return os.DirFS(path), files, nil
+}
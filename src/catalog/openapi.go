@@ -0,0 +1,90 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type openAPICacheItem struct {
+	spec       map[string]interface{}
+	expiration time.Time
+	// size is the estimated size of spec, counted against CACHE_MEMORY_BUDGET.
+	size int
+}
+
+// OpenAPISpec downloads and parses the OpenAPI document at specURL,
+// caching it for 5 minutes under the dataset ID. The document is shared by
+// concurrent callers and must not be modified.
+func (c *Client) OpenAPISpec(ctx context.Context, id, specURL string) (map[string]interface{}, error) {
+	c.openAPICacheMutex.RLock()
+	if item, found := c.openAPICache[id]; found && time.Now().Before(item.expiration) {
+		c.openAPICacheMutex.RUnlock()
+		c.CountHit("openapi")
+		return item.spec, nil
+	}
+	c.openAPICacheMutex.RUnlock()
+	c.CountMiss("openapi")
+
+	resp, err := c.upstreamGet(ctx, endpointOpenAPI, specURL)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, specURL)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// YAML is a superset of JSON, so this handles both serializations.
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(body, &spec); err != nil {
+		return nil, err
+	}
+	// Response codes are usually unquoted, e.g. 200:, which YAML decodes as
+	// integer keys that JSON cannot encode.
+	for k, v := range spec {
+		spec[k] = stringKeys(v)
+	}
+
+	c.openAPICacheMutex.Lock()
+	c.openAPICache[id] = openAPICacheItem{
+		spec:       spec,
+		expiration: time.Now().Add(jitteredTTL()),
+		size:       encodedSize(spec),
+	}
+	c.openAPICacheMutex.Unlock()
+	c.EnforceCacheBudget()
+	return spec, nil
+}
+
+// stringKeys returns v, decoded from YAML, with the keys of every mapping
+// converted to strings, e.g. the response code 200 to "200".
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, member := range v {
+			out[fmt.Sprint(k)] = stringKeys(member)
+		}
+		return out
+	case map[string]interface{}:
+		for k, member := range v {
+			v[k] = stringKeys(member)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return v
+}
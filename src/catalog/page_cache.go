@@ -0,0 +1,111 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pageCache stores the upstream pages served by fetchDatasets. The backend is
+// selected with UseCacheBackend.
+type pageCache interface {
+	get(key pageKey) (cacheItem, bool)
+	set(key pageKey, item cacheItem)
+	// remove deletes the entries whose key matches and returns their number.
+	remove(match func(pageKey) bool) int
+	// removeExpired deletes the entries that expired before cutoff and returns
+	// their number.
+	removeExpired(cutoff time.Time) int
+	// expirations returns the expiration times of the entries.
+	expirations() []time.Time
+	// name returns the backend name, as passed to UseCacheBackend.
+	name() string
+	// ping checks that the backend is reachable.
+	ping() error
+}
+
+// UseCacheBackend selects the page cache backend: "memory" (the default) or
+// "memcached", which connects to the servers listed in MEMCACHED_SERVERS.
+func (c *Client) UseCacheBackend(backend string) error {
+	switch backend {
+	case "", "memory":
+		c.datasetCache = newMemoryPageCache()
+	case "memcached":
+		cache, err := newMemcachedPageCache(c.cfg.Cache.MemcachedServers, c.cfg.Cache.MaxStaleness)
+		if err != nil {
+			return err
+		}
+		c.datasetCache = cache
+	default:
+		return fmt.Errorf("unknown cache backend %q", backend)
+	}
+	return nil
+}
+
+// memoryPageCache keeps pages in a map of this process.
+type memoryPageCache struct {
+	mu    sync.RWMutex
+	items map[pageKey]cacheItem
+}
+
+func newMemoryPageCache() *memoryPageCache {
+	return &memoryPageCache{items: make(map[pageKey]cacheItem)}
+}
+
+func (m *memoryPageCache) name() string { return "memory" }
+
+func (m *memoryPageCache) ping() error { return nil }
+
+func (m *memoryPageCache) get(key pageKey) (cacheItem, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, found := m.items[key]
+	return item, found
+}
+
+func (m *memoryPageCache) set(key pageKey, item cacheItem) {
+	item.size = encodedSize(item.data)
+	item.kept = new(keptCounts)
+	m.mu.Lock()
+	m.items[key] = item
+	m.mu.Unlock()
+}
+
+func (m *memoryPageCache) remove(match func(pageKey) bool) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key := range m.items {
+		if match(key) {
+			delete(m.items, key)
+			n++
+		}
+	}
+	return n
+}
+
+func (m *memoryPageCache) removeExpired(cutoff time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key, item := range m.items {
+		if item.expiration.Before(cutoff) {
+			delete(m.items, key)
+			n++
+		}
+	}
+	return n
+}
+
+func (m *memoryPageCache) expirations() []time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]time.Time, 0, len(m.items))
+	for _, item := range m.items {
+		out = append(out, item.expiration)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// pagesBucket holds the raw upstream responses, keyed by page and page size.
+var pagesBucket = []byte("pages")
+
+// OpenPersistentCache opens (or creates) the persistent cache at path.
+func (c *Client) OpenPersistentCache(path string) error {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(pagesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	c.persistentCache = db
+	return nil
+}
+
+func (k pageKey) bytes() []byte {
+	return []byte(k.url())
+}
+
+// persistPage stores an upstream page. Failures are logged only, as the
+// persistent cache is a fallback.
+func (c *Client) persistPage(key pageKey, data *MetaDataPage) {
+	if c.persistentCache == nil {
+		return
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding page %d for persistent cache: %v", key.page, err)
+		return
+	}
+	err = c.persistentCache.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pagesBucket).Put(key.bytes(), body)
+	})
+	if err != nil {
+		log.Printf("Error writing page %d to persistent cache: %v", key.page, err)
+	}
+}
+
+// loadPersistedPage returns the stored response for key in place of a failed
+// upstream fetch, or fetchErr if there is none.
+func (c *Client) loadPersistedPage(key pageKey, fetchErr error) (*MetaDataPage, error) {
+	if c.persistentCache == nil {
+		return nil, fetchErr
+	}
+	var data *MetaDataPage
+	err := c.persistentCache.View(func(tx *bolt.Tx) error {
+		body := tx.Bucket(pagesBucket).Get(key.bytes())
+		if body == nil {
+			return nil
+		}
+		data = &MetaDataPage{}
+		return json.Unmarshal(body, data)
+	})
+	if err != nil || data == nil {
+		return nil, fetchErr
+	}
+	log.Printf("Upstream unavailable (%v), serving page %d from persistent cache", fetchErr, key.page)
+	// The overrides may have changed since the page was persisted.
+	data.Items = c.applyOverrides(data.Items)
+	return data, nil
+}
@@ -0,0 +1,173 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// walkWindow is how long after a page was requested a request for the next
+// page counts as a sequential walk through the listing.
+const walkWindow = time.Minute
+
+// maxRecentPages bounds the number of requested pages remembered to detect
+// sequential walks.
+const maxRecentPages = 10000
+
+// recentPage identifies a page of a listing requested from the API.
+type recentPage struct {
+	page     int
+	pageSize int
+	filters  string
+}
+
+// prefetchMetrics count the prefetched pages of a Client and their uses.
+type prefetchMetrics struct {
+	upstreamPrefetches   *prometheus.CounterVec
+	upstreamPrefetchUses *prometheus.CounterVec
+}
+
+func newPrefetchMetrics() prefetchMetrics {
+	return prefetchMetrics{
+		upstreamPrefetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_prefetches_total",
+			Help: `Upstream pages prefetched for sequential walks, by result ("fetched" or "failed").`,
+		}, []string{"result"}),
+		upstreamPrefetchUses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_prefetch_uses_total",
+			Help: `Prefetched pages by use: "hit" when a request read the page, "waited" when it waited for the prefetch in flight, "unused" when the page expired unread.`,
+		}, []string{"use"}),
+	}
+}
+
+func (m prefetchMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.upstreamPrefetches, m.upstreamPrefetchUses}
+}
+
+// prefetchDepth returns the number of upstream pages prefetched after the
+// last one a sequential walk read, UPSTREAM_PREFETCH_DEPTH (default 1).
+func (c *Client) prefetchDepth() int {
+	return c.cfg.Upstream.PrefetchDepth
+}
+
+// prefetchAfter notes that page of the listing of pageSize datasets matching
+// filters was requested and, if the page before was requested within
+// walkWindow, as by a harvester walking the listing, prefetches in the
+// background the prefetchDepth upstream pages after lastUpstream, the last
+// upstream page the request read, up to the end of the catalog of total
+// datasets. Other requests, such as of the first page only, prefetch nothing.
+func (c *Client) prefetchAfter(page, pageSize int, filters url.Values, lastUpstream, total int) {
+	depth := c.prefetchDepth()
+	if depth == 0 {
+		return
+	}
+	encoded := filters.Encode()
+	now := time.Now()
+	c.prefetchMutex.Lock()
+	if len(c.recentPages) >= maxRecentPages {
+		for key, requested := range c.recentPages {
+			if now.Sub(requested) > walkWindow {
+				delete(c.recentPages, key)
+			}
+		}
+	}
+	previous, found := c.recentPages[recentPage{page - 1, pageSize, encoded}]
+	if len(c.recentPages) < maxRecentPages {
+		c.recentPages[recentPage{page, pageSize, encoded}] = now
+	}
+	c.prefetchMutex.Unlock()
+	if !found || now.Sub(previous) > walkWindow || c.UpstreamFailing() {
+		return
+	}
+
+	size := c.upstreamPageSize()
+	last := min(lastUpstream+depth, (total+size-1)/size)
+	for upstreamPage := lastUpstream + 1; upstreamPage <= last; upstreamPage++ {
+		c.prefetchPage(newPageKey(upstreamPage, size, filters))
+	}
+}
+
+// prefetchPage fetches the page with key into the cache in the background,
+// unless it is being prefetched already or is fresh in the cache.
+func (c *Client) prefetchPage(key pageKey) {
+	c.prefetchMutex.Lock()
+	if _, found := c.prefetching[key]; found {
+		c.prefetchMutex.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	c.prefetching[key] = done
+	c.prefetchMutex.Unlock()
+
+	go func() {
+		defer func() {
+			c.prefetchMutex.Lock()
+			delete(c.prefetching, key)
+			c.prefetchMutex.Unlock()
+			close(done)
+		}()
+		if item, found := c.datasetCache.get(key); found && time.Now().Before(item.expiration) {
+			return
+		}
+		if _, err := c.refreshDatasets(context.Background(), key); err != nil {
+			c.upstreamPrefetches.WithLabelValues("failed").Inc()
+			log.Printf("Error prefetching page %d: %v", key.page, err)
+			return
+		}
+		c.upstreamPrefetches.WithLabelValues("fetched").Inc()
+		c.prefetchMutex.Lock()
+		c.dropUnusedPrefetches()
+		c.prefetched[key] = time.Now()
+		c.prefetchMutex.Unlock()
+	}()
+}
+
+// dropUnusedPrefetches forgets the prefetched pages that expired unread,
+// counting them as unused. prefetchMutex must be held.
+func (c *Client) dropUnusedPrefetches() {
+	for key, stored := range c.prefetched {
+		if time.Since(stored) > CacheTTL {
+			delete(c.prefetched, key)
+			c.upstreamPrefetchUses.WithLabelValues("unused").Inc()
+		}
+	}
+}
+
+// notePrefetchHit counts the first read of a prefetched page from the cache.
+func (c *Client) notePrefetchHit(key pageKey) {
+	c.prefetchMutex.Lock()
+	if _, found := c.prefetched[key]; found {
+		delete(c.prefetched, key)
+		c.upstreamPrefetchUses.WithLabelValues("hit").Inc()
+	}
+	c.prefetchMutex.Unlock()
+}
+
+// awaitPrefetch waits until a prefetch of the page with key in flight is done
+// and reports whether there was one, so the page is not fetched twice.
+func (c *Client) awaitPrefetch(ctx context.Context, key pageKey) bool {
+	c.prefetchMutex.Lock()
+	done, found := c.prefetching[key]
+	c.prefetchMutex.Unlock()
+	if !found {
+		return false
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return false
+	}
+	c.prefetchMutex.Lock()
+	if _, found := c.prefetched[key]; found {
+		delete(c.prefetched, key)
+		c.upstreamPrefetchUses.WithLabelValues("waited").Inc()
+	}
+	c.prefetchMutex.Unlock()
+	return true
+}
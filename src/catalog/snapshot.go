@@ -0,0 +1,116 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+func (c *Client) setSnapshot(datasets []transformers.Dataset) {
+	datasets = c.applyOverrides(datasets)
+	c.snapshot = datasets
+	c.snapshotIndex = newCatalogIndex()
+	c.snapshotIndex.add(datasets)
+	c.snapshotIndex.indexSlugs()
+}
+
+// LoadSnapshot seeds the cache with an exported catalog, read from a file path
+// or an http(s) URL, so the service can run without upstream connectivity.
+// Accepted formats are the /export/ndjson output, a JSON array of datasets and
+// an upstream MetaData response.
+func (c *Client) LoadSnapshot(source string) error {
+	var body []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		body, err = c.downloadSnapshot(source)
+	} else {
+		body, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return err
+	}
+	datasets, err := parseSnapshot(body)
+	if err != nil {
+		return err
+	}
+	if len(datasets) == 0 {
+		return errors.New("snapshot contains no datasets")
+	}
+	c.setSnapshot(datasets)
+	log.Printf("Loaded %d datasets from snapshot %s", len(datasets), source)
+	return nil
+}
+
+func (c *Client) downloadSnapshot(url string) ([]byte, error) {
+	resp, err := c.upstreamGet(context.Background(), endpointSnapshot, url)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseSnapshot decodes the datasets of an exported catalog.
+func parseSnapshot(body []byte) ([]transformers.Dataset, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		var datasets []transformers.Dataset
+		err := json.Unmarshal(body, &datasets)
+		return datasets, err
+	}
+	var page MetaDataPage
+	if err := json.Unmarshal(body, &page); err == nil && len(page.Items) > 0 {
+		return page.Items, nil
+	}
+	// One dataset per line.
+	var datasets []transformers.Dataset
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var ds transformers.Dataset
+		if err := dec.Decode(&ds); err == io.EOF {
+			return datasets, nil
+		} else if err != nil {
+			return nil, err
+		}
+		datasets = append(datasets, ds)
+	}
+}
+
+// snapshotPage returns the page of the snapshot matching key, or nil if no
+// snapshot is loaded or key is not an unfiltered page of the MetaData API.
+func (c *Client) snapshotPage(key pageKey) *MetaDataPage {
+	if c.snapshot == nil || key.source != metaDataURL || key.filters != "" {
+		return nil
+	}
+	total := len(c.snapshot)
+	page := &MetaDataPage{
+		TotalResults: total,
+		TotalPages:   (total + key.pageSize - 1) / key.pageSize,
+		CurrentPage:  key.page,
+	}
+	start := (key.page - 1) * key.pageSize
+	if start < total {
+		page.Items = c.snapshot[start:min(start+key.pageSize, total)]
+	}
+	return page
+}
+
+// snapshotDataset returns the snapshot dataset with the given ID or slug, or nil.
+func (c *Client) snapshotDataset(id string) *transformers.Dataset {
+	return c.snapshotIndex.lookup(id)
+}
@@ -0,0 +1,219 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// SyncStatus describes the scheduled catalog synchronization.
+type SyncStatus struct {
+	Schedule    string    `json:"schedule"`
+	Running     bool      `json:"running"`
+	LastRun     time.Time `json:"lastRun"`
+	LastSuccess time.Time `json:"lastSuccess"`
+	Duration    string    `json:"duration"`
+	Pages       int       `json:"pages"`
+	Datasets    int       `json:"datasets"`
+	// Unchanged reports that the last sync found the catalog as the one before.
+	Unchanged bool      `json:"unchanged"`
+	Error     string    `json:"error,omitempty"`
+	NextRun   time.Time `json:"nextRun"`
+}
+
+// OnSync makes every successful sync call fn in a new goroutine with when the
+// sync started and whether the catalog changed since the sync before. Call it
+// before StartSyncScheduler.
+func (c *Client) OnSync(fn func(started time.Time, changed bool)) {
+	c.syncHooks = append(c.syncHooks, fn)
+}
+
+// errUpstreamUnavailable is returned by syncCatalog when a page could only be
+// served from last-known-good data.
+var errUpstreamUnavailable = errors.New("upstream API unavailable")
+
+// StartSyncScheduler re-syncs every upstream page into the cache on the given
+// cron schedule (standard five-field syntax, e.g. "*/10 * * * *"), so data
+// freshness does not depend on request traffic. A first sync runs immediately.
+func (c *Client) StartSyncScheduler(schedule string) error {
+	c.syncScheduler = cron.New()
+	if _, err := c.syncScheduler.AddFunc(schedule, c.runSync); err != nil {
+		return err
+	}
+	c.catalogSyncMutex.Lock()
+	c.catalogSync.Schedule = schedule
+	c.catalogSyncMutex.Unlock()
+	c.syncScheduler.Start()
+	go c.runSync()
+	return nil
+}
+
+// runSync runs syncCatalog and records its outcome, skipping the run if the
+// previous one is still in progress.
+func (c *Client) runSync() {
+	c.catalogSyncMutex.Lock()
+	if c.catalogSync.Running {
+		c.catalogSyncMutex.Unlock()
+		return
+	}
+	c.catalogSync.Running = true
+	c.catalogSync.LastRun = time.Now()
+	c.catalogSyncMutex.Unlock()
+
+	start := time.Now()
+	pages, datasets, changed, err := c.syncCatalog()
+
+	c.catalogSyncMutex.Lock()
+	defer c.catalogSyncMutex.Unlock()
+	c.catalogSync.Running = false
+	c.catalogSync.Duration = time.Since(start).Round(time.Millisecond).String()
+	c.catalogSync.Pages = pages
+	c.catalogSync.Datasets = datasets
+	c.catalogSync.Error = ""
+	if err != nil {
+		log.Printf("Catalog sync failed after %d pages: %v", pages, err)
+		c.catalogSync.Error = err.Error()
+		return
+	}
+	c.catalogSync.LastSuccess = time.Now()
+	c.catalogSync.Unchanged = !changed
+	if changed {
+		log.Printf("Catalog sync completed: %d pages, %d datasets", pages, datasets)
+	} else {
+		log.Printf("Catalog sync completed: %d pages, %d datasets, unchanged", pages, datasets)
+	}
+	for _, fn := range c.syncHooks {
+		go fn(start, changed)
+	}
+}
+
+// contentDigest returns a digest of the datasets of a page and the total
+// number of datasets it reported, which changes with any field of them.
+func contentDigest(total int, items []transformers.Dataset) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", total)
+	if err := json.NewEncoder(h).Encode(items); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return [sha256.Size]byte(h.Sum(nil)), nil
+}
+
+// syncCatalog fetches every upstream page with walkPages, bypassing the page
+// cache, stores it in the cache and rebuilds the catalog index. It returns the
+// number of pages and datasets synchronized and whether the content of any
+// page, or the number of pages, differs from the last successful sync.
+func (c *Client) syncCatalog() (int, int, bool, error) {
+	ctx := context.Background()
+	index := newCatalogIndex()
+	var digests [][sha256.Size]byte
+	// Pages without a digest count as changed.
+	digested := true
+	addDigest := func(total int, items []transformers.Dataset) {
+		digest, err := contentDigest(total, items)
+		if err != nil {
+			log.Printf("Error computing the digest of a synced page: %v", err)
+			digested = false
+		}
+		digests = append(digests, digest)
+	}
+	err := c.walkPages(ctx, newPageKey(1, c.upstreamPageSize(), nil), c.syncPage, func(_ pageKey, data *MetaDataPage) error {
+		index.add(data.Items)
+		addDigest(data.TotalResults, data.Items)
+		return nil
+	})
+	if err != nil {
+		return len(digests), len(index.byID), true, err
+	}
+	pages := len(digests)
+	mobility := c.mobilityDatasets(ctx)
+	index.add(mobility)
+	addDigest(len(mobility), mobility)
+	// The datasets expire with the responses prerendered after the sync, when
+	// the next sync is overdue.
+	c.setCatalogIndex(index, c.SyncExpiration())
+
+	c.syncDigestsMutex.Lock()
+	changed := !digested || !slices.Equal(digests, c.syncDigests)
+	c.syncDigests = digests
+	c.syncDigestsMutex.Unlock()
+	return pages, len(index.byID), changed, nil
+}
+
+// SyncCatalog runs a sync like the scheduled one once, for the sync command,
+// and returns the number of pages and datasets synced.
+func (c *Client) SyncCatalog() (int, int, error) {
+	pages, datasets, _, err := c.syncCatalog()
+	return pages, datasets, err
+}
+
+// syncPage fetches an upstream page, bypassing the page cache, and stores it
+// in the cache. Last-known-good data is reported as errUpstreamUnavailable.
+func (c *Client) syncPage(ctx context.Context, key pageKey) (*MetaDataPage, error) {
+	data, err := c.fetchUpstreamPage(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data.stale {
+		return nil, errUpstreamUnavailable
+	}
+	c.storePage(key, data)
+	return data, nil
+}
+
+// CurrentSyncStatus returns the state of the scheduled catalog
+// synchronization, and false if it is disabled.
+func (c *Client) CurrentSyncStatus() (SyncStatus, bool) {
+	c.catalogSyncMutex.Lock()
+	status := c.catalogSync
+	c.catalogSyncMutex.Unlock()
+	if c.syncScheduler == nil {
+		return status, false
+	}
+	if entries := c.syncScheduler.Entries(); len(entries) > 0 {
+		status.NextRun = entries[0].Next
+	}
+	return status, true
+}
+
+// SyncExpiration returns when data refreshed by a sync, such as the catalog
+// index or responses rendered after it, expires: the cache TTL after the next
+// scheduled sync, which normally replaces it before, or after now without
+// scheduled syncs.
+func (c *Client) SyncExpiration() time.Time {
+	next := time.Now()
+	if c.syncScheduler != nil {
+		if entries := c.syncScheduler.Entries(); len(entries) > 0 {
+			next = entries[0].Next
+		}
+	}
+	return next.Add(CacheTTL)
+}
+
+// StopBackground stops the scheduled sync, waiting until ctx is done for a
+// running sync to finish, and closes the persistent cache, which flushes it to
+// disk.
+func (c *Client) StopBackground(ctx context.Context) {
+	if c.syncScheduler != nil {
+		select {
+		case <-c.syncScheduler.Stop().Done():
+		case <-ctx.Done():
+			log.Printf("Not waiting for the running sync: %v", ctx.Err())
+		}
+	}
+	if c.persistentCache != nil {
+		if err := c.persistentCache.Close(); err != nil {
+			log.Printf("Error closing persistent cache: %v", err)
+		}
+	}
+}
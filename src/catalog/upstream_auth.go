@@ -0,0 +1,83 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// tokenRenewal is how long before its expiry an access token is renewed.
+const tokenRenewal = 30 * time.Second
+
+// upstreamToken returns the bearer token sent to the Open Data Hub APIs:
+// UPSTREAM_TOKEN if set, or else an access token obtained from
+// UPSTREAM_TOKEN_URL (e.g. a Keycloak token endpoint) with the OAuth
+// client-credentials flow, using UPSTREAM_CLIENT_ID and UPSTREAM_CLIENT_SECRET.
+// Access tokens are reused until shortly before they expire. It returns "" if
+// no authentication is configured.
+func (c *Client) upstreamToken(ctx context.Context) (string, error) {
+	if token := c.cfg.Upstream.Token; token != "" {
+		return token, nil
+	}
+	tokenURL := c.cfg.Upstream.TokenURL
+	if tokenURL == "" {
+		return "", nil
+	}
+	c.accessTokenMutex.Lock()
+	defer c.accessTokenMutex.Unlock()
+	if c.accessToken != "" && time.Now().Before(c.accessTokenExpiration) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.cfg.Upstream.ClientID},
+		"client_secret": {c.cfg.Upstream.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.doUpstream(endpointToken, req)
+	if err != nil {
+		return "", err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from token endpoint", resp.StatusCode)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("token endpoint returned no access token")
+	}
+	c.accessToken = token.AccessToken
+	c.accessTokenExpiration = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenRenewal)
+	return c.accessToken, nil
+}
+
+// authorizedHost reports whether requests to host get the upstream token.
+// Only the MetaData API, its fallback and the mobility API do, so the token is never sent to the
+// third-party hosts of OpenAPI documents or snapshots.
+func (c *Client) authorizedHost(host string) bool {
+	for _, api := range []string{metaDataURL, c.fallbackURL(), c.cfg.Upstream.MobilityAPIURL} {
+		if u, err := url.Parse(api); err == nil && u.Host != "" && u.Host == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errUpstreamThrottled is returned for upstream requests that were throttled,
+// or skipped because the upstream host asked to back off.
+var errUpstreamThrottled = errors.New("upstream API throttled")
+
+// defaultBackoff is how long to back off after a 429 without Retry-After.
+const defaultBackoff = 30 * time.Second
+
+// backoffMetrics count the throttled and skipped upstream requests of a
+// Client, by host.
+type backoffMetrics struct {
+	upstreamThrottled *prometheus.CounterVec
+	upstreamSkipped   *prometheus.CounterVec
+}
+
+func newBackoffMetrics() backoffMetrics {
+	return backoffMetrics{
+		upstreamThrottled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_throttled_total",
+			Help: "Upstream responses asking to back off (429, or 503 with Retry-After), by host.",
+		}, []string{"host"}),
+		upstreamSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_skipped_total",
+			Help: "Upstream requests not sent while backing off, by host.",
+		}, []string{"host"}),
+	}
+}
+
+// backoffCollectors returns the backoff metrics, including the remaining
+// backoff of the host backed off the longest.
+func (c *Client) backoffCollectors() []prometheus.Collector {
+	return []prometheus.Collector{c.upstreamThrottled, c.upstreamSkipped, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "catalog_upstream_backoff_seconds",
+		Help: "Remaining time until requests are sent to the upstream host backed off the longest.",
+	}, func() float64 {
+		return c.longestBackoff().Seconds()
+	})}
+}
+
+// checkBackoff returns errUpstreamThrottled if host asked to back off and the
+// time it asked for has not passed yet.
+func (c *Client) checkBackoff(host string) error {
+	c.backoffUntilMutex.Lock()
+	until := c.backoffUntil[host]
+	c.backoffUntilMutex.Unlock()
+	if wait := time.Until(until); wait > 0 {
+		c.upstreamSkipped.WithLabelValues(host).Inc()
+		return fmt.Errorf("%w: backing off for %s", errUpstreamThrottled, wait.Round(time.Second))
+	}
+	return nil
+}
+
+// checkThrottled starts a backoff if resp is a 429, or a 503 with a
+// Retry-After header, and returns errUpstreamThrottled in that case. The
+// backoff lasts as long as Retry-After asks for, at most UPSTREAM_MAX_BACKOFF
+// (default 10m).
+func (c *Client) checkThrottled(host string, resp *http.Response) error {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	wait, found := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if !found {
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			return nil
+		}
+		wait = defaultBackoff
+	}
+	wait = min(wait, c.cfg.Upstream.MaxBackoff)
+
+	c.upstreamThrottled.WithLabelValues(host).Inc()
+	c.backoffUntilMutex.Lock()
+	c.backoffUntil[host] = time.Now().Add(wait)
+	c.backoffUntilMutex.Unlock()
+	log.Printf("Upstream %s answered %d, backing off for %s", host, resp.StatusCode, wait)
+	return fmt.Errorf("%w: status %d", errUpstreamThrottled, resp.StatusCode)
+}
+
+// parseRetryAfter parses a Retry-After header, given in seconds or as an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// longestBackoff returns the longest remaining backoff of any upstream host.
+func (c *Client) longestBackoff() time.Duration {
+	c.backoffUntilMutex.Lock()
+	defer c.backoffUntilMutex.Unlock()
+	var longest time.Duration
+	for _, until := range c.backoffUntil {
+		longest = max(longest, time.Until(until))
+	}
+	return longest
+}
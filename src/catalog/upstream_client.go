@@ -0,0 +1,162 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/time/rate"
+)
+
+// upstreamHTTP2 reports whether upstream requests negotiate HTTP/2, which
+// UPSTREAM_HTTP2=false switches off.
+func (c *Client) upstreamHTTP2() bool {
+	return c.cfg.Upstream.HTTP2
+}
+
+// upstreamGet sends a GET request for url with the shared client, waiting for
+// the rate limit first, and records it in the upstream metrics under endpoint.
+// Requests to the Open Data Hub APIs carry the upstreamToken, if configured.
+// The request is abandoned as soon as ctx is done, e.g. when the client
+// disconnects. While the host asks to back off (see checkThrottled), requests
+// fail with errUpstreamThrottled without being sent. Requests to the MetaData
+// API go to UPSTREAM_FALLBACK_URL instead while failed over (see recordPrimary).
+// In offline mode, all requests fail with errOffline.
+func (c *Client) upstreamGet(ctx context.Context, endpoint, url string) (*http.Response, error) {
+	if c.OfflineMode() {
+		return nil, errOffline
+	}
+	target := c.failoverURL(url)
+	resp, err := c.sendUpstream(ctx, endpoint, target)
+	if target != url || !strings.HasPrefix(url, metaDataURL) {
+		return resp, err
+	}
+	if c.recordPrimary(primaryFailed(ctx, resp, err)) {
+		// This failure started the failover; retry on the fallback right away.
+		if err == nil {
+			closeBody(resp)
+		}
+		return c.sendUpstream(ctx, endpoint, c.failoverURL(url))
+	}
+	return resp, err
+}
+
+func (c *Client) sendUpstream(ctx context.Context, endpoint, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkBackoff(req.URL.Host); err != nil {
+		return nil, err
+	}
+	if err := c.upstreamLimiter().Wait(ctx); err != nil {
+		return nil, err
+	}
+	if c.authorizedHost(req.URL.Host) {
+		token, err := c.upstreamToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("obtaining upstream token: %w", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	resp, err := c.doUpstream(endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkThrottled(req.URL.Host, resp); err != nil {
+		closeBody(resp)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// closeBody drains and closes an upstream response body, so the connection
+// is kept alive and reused even if the body was not read to the end.
+func closeBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+}
+
+// maxDrain bounds the unread bytes closeBody discards; connections with more
+// left are closed instead.
+const maxDrain = 256 << 10
+
+// newUpstreamClient returns the HTTP client shared by all upstream requests. It
+// gives up connecting after UPSTREAM_CONNECT_TIMEOUT (default 5s) and on the
+// whole request, including reading the response, after UPSTREAM_TIMEOUT
+// (default 30s), so a hanging upstream API cannot block requests indefinitely.
+// Requests go through UPSTREAM_PROXY if set, and otherwise through the proxy
+// given by HTTP_PROXY, HTTPS_PROXY and NO_PROXY. TLS is configured by
+// upstreamTLSConfig. Responses are recorded to or replayed from disk if
+// configured (see recordingRoundTripper). The transport asks for gzip and
+// decompresses responses transparently, as long as requests leave
+// Accept-Encoding unset.
+//
+// Connections are kept alive and reused across requests; the idle pool holds
+// at least FETCH_WORKERS connections per host, so catalog walks do not open
+// (and TLS handshake) a new connection per page. HTTP/2 is negotiated unless
+// UPSTREAM_HTTP2=false, multiplexing all requests to a host over one
+// connection, which is pinged after UPSTREAM_HTTP2_PING_INTERVAL (default
+// 30s) without traffic and dropped if it does not answer.
+func (c *Client) newUpstreamClient() *http.Client {
+	connectTimeout := c.cfg.Upstream.ConnectTimeout
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: c.cfg.Upstream.KeepAlive,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.MaxIdleConns = c.cfg.Upstream.MaxIdleConns
+	transport.MaxIdleConnsPerHost = c.cfg.Upstream.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = c.cfg.Upstream.MaxConnsPerHost
+	transport.IdleConnTimeout = c.cfg.Upstream.IdleConnTimeout
+	if tlsConfig, err := c.upstreamTLSConfig(); err != nil {
+		log.Printf("Invalid upstream TLS configuration, using the defaults: %v", err)
+	} else {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if proxy := c.cfg.Upstream.Proxy; proxy != "" {
+		if proxyURL, err := url.Parse(proxy); err != nil || proxyURL.Host == "" {
+			log.Printf("Invalid UPSTREAM_PROXY %q, using the environment proxy settings", proxy)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	// A custom dialer and TLS configuration disable HTTP/2 unless asked for.
+	transport.ForceAttemptHTTP2 = c.upstreamHTTP2()
+	if transport.ForceAttemptHTTP2 {
+		if h2, err := http2.ConfigureTransports(transport); err != nil {
+			log.Printf("Error configuring upstream HTTP/2 health checks: %v", err)
+		} else {
+			h2.ReadIdleTimeout = c.cfg.Upstream.HTTP2PingInterval
+			h2.PingTimeout = connectTimeout
+		}
+	}
+	return &http.Client{
+		Transport: c.recordingRoundTripper(transport),
+		Timeout:   c.cfg.Upstream.Timeout,
+	}
+}
+
+// newUpstreamLimiter returns the token bucket shared by all upstream requests.
+// It allows UPSTREAM_RPS requests per second (default 10) with bursts of up to
+// UPSTREAM_BURST (default 20), so cache stampedes and catalog walks cannot
+// overwhelm the upstream API. UPSTREAM_RPS=0 disables the limit.
+func (c *Client) newUpstreamLimiter() *rate.Limiter {
+	rps := c.cfg.Upstream.RPS
+	if rps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(rps), c.cfg.Upstream.Burst)
+}
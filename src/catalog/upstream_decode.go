@@ -0,0 +1,93 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// decodeMetaDataPage decodes an upstream MetaData page from r token by token.
+// Items are decoded one at a time as they arrive and checked for schema drift,
+// so the raw response is never held in memory as a whole, which keeps the peak
+// memory of large pages and catalog walks low.
+func (c *Client) decodeMetaDataPage(r io.Reader) (*MetaDataPage, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	var data MetaDataPage
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		field, _ := tok.(string)
+		switch field {
+		case "TotalResults":
+			err = dec.Decode(&data.TotalResults)
+		case "TotalPages":
+			err = dec.Decode(&data.TotalPages)
+		case "CurrentPage":
+			err = dec.Decode(&data.CurrentPage)
+		case "NextPage":
+			err = dec.Decode(&data.NextPage)
+		case "Items":
+			data.Items, err = c.decodeItems(dec)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", field, err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// decodeItems decodes the Items array of a MetaData page one dataset at a time.
+func (c *Client) decodeItems(dec *json.Decoder) ([]transformers.Dataset, error) {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected array, got %v", tok)
+	}
+	var items []transformers.Dataset
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		var ds transformers.Dataset
+		if err := json.Unmarshal(raw, &ds); err != nil {
+			return nil, err
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) == nil {
+			c.checkDatasetDrift(fields)
+		}
+		items = append(items, ds)
+	}
+	return items, expectDelim(dec, ']')
+}
+
+// expectDelim reads the next token of dec and fails unless it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// requiredDatasetFields are the upstream fields every dataset must have for
+// the transformers to produce usable output.
+var requiredDatasetFields = []string{"Id", "Shortname", "ApiUrl"}
+
+// datasetFields returns the JSON field names of transformers.Dataset.
+var datasetFields = sync.OnceValue(func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(transformers.Dataset{})
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+})
+
+func newDriftCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalog_upstream_drift_total",
+		Help: "Upstream datasets with a field the catalog does not know (kind=unknown) or a required field missing (kind=missing).",
+	}, []string{"kind", "field"})
+}
+
+// checkDatasetDrift compares a raw upstream dataset with transformers.Dataset
+// and reports unknown fields and missing required ones, so changes of the
+// upstream schema are noticed before they silently break the transformers.
+func (c *Client) checkDatasetDrift(item map[string]json.RawMessage) {
+	var id string
+	json.Unmarshal(item["Id"], &id)
+	for field := range item {
+		if !datasetFields()[field] {
+			c.reportDrift("unknown", field, id)
+		}
+	}
+	for _, field := range requiredDatasetFields {
+		if v, found := item[field]; !found || string(v) == "null" || string(v) == `""` {
+			c.reportDrift("missing", field, id)
+		}
+	}
+}
+
+// reportDrift counts a drift and logs it the first time it is seen.
+func (c *Client) reportDrift(kind, field, id string) {
+	c.upstreamDrift.WithLabelValues(kind, field).Inc()
+	c.driftReportedMutex.Lock()
+	reported := c.driftReported[kind+" "+field]
+	c.driftReported[kind+" "+field] = true
+	c.driftReportedMutex.Unlock()
+	if !reported {
+		log.Printf("Upstream schema drift: kind=%s field=%s dataset=%s", kind, field, id)
+	}
+}
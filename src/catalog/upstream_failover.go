@@ -0,0 +1,106 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FailoverStatus is the failover state reported by the health checks.
+type FailoverStatus struct {
+	Active              bool   `json:"active"`
+	URL                 string `json:"url"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Since               string `json:"since,omitempty"`
+	Until               string `json:"until,omitempty"`
+}
+
+// fallbackURL returns UPSTREAM_FALLBACK_URL, a mirror of the MetaData API
+// (e.g. https://api.tourism.testingmachine.eu/v1/MetaData), or "" if unset.
+func (c *Client) fallbackURL() string {
+	return c.cfg.Upstream.FallbackURL
+}
+
+// failoverURL returns the URL to request instead of rawURL, which is rawURL
+// itself unless it targets the MetaData API while failed over.
+func (c *Client) failoverURL(rawURL string) string {
+	fallback := c.fallbackURL()
+	if fallback == "" || !strings.HasPrefix(rawURL, metaDataURL) {
+		return rawURL
+	}
+	c.failoverMutex.Lock()
+	active := time.Now().Before(c.failoverUntil)
+	c.failoverMutex.Unlock()
+	if !active {
+		return rawURL
+	}
+	return fallback + strings.TrimPrefix(rawURL, metaDataURL)
+}
+
+// recordPrimary records the outcome of a request to the primary MetaData API.
+// After UPSTREAM_FAILOVER_THRESHOLD (default 3) consecutive failures, requests
+// go to the fallback for UPSTREAM_FAILOVER_DURATION (default 5m). Then the
+// primary is tried again: a success ends the failover, a failure restarts it.
+// It reports whether a failover has just started.
+func (c *Client) recordPrimary(failed bool) bool {
+	if c.fallbackURL() == "" {
+		return false
+	}
+	c.failoverMutex.Lock()
+	defer c.failoverMutex.Unlock()
+	if !failed {
+		if !c.failoverSince.IsZero() {
+			log.Printf("Upstream %s recovered, leaving failover", metaDataURL)
+		}
+		c.primaryFailures = 0
+		c.failoverSince, c.failoverUntil = time.Time{}, time.Time{}
+		return false
+	}
+	c.primaryFailures++
+	if c.primaryFailures < c.cfg.Upstream.FailoverThreshold {
+		return false
+	}
+	duration := c.cfg.Upstream.FailoverDuration
+	if c.failoverSince.IsZero() {
+		c.failoverSince = time.Now()
+	}
+	c.failoverUntil = time.Now().Add(duration)
+	log.Printf("Upstream %s failed %d times in a row, failing over to %s for %s", metaDataURL, c.primaryFailures, c.fallbackURL(), duration)
+	return true
+}
+
+// primaryFailed reports whether a request to the primary MetaData API failed:
+// it could not be sent, the server errored, or asked to back off. Requests
+// abandoned by their caller are not failures of the API.
+func primaryFailed(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil || errors.Is(err, errUpstreamThrottled)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// UpstreamFailover returns the failover state, or nil without a fallback.
+func (c *Client) UpstreamFailover() *FailoverStatus {
+	fallback := c.fallbackURL()
+	if fallback == "" {
+		return nil
+	}
+	c.failoverMutex.Lock()
+	defer c.failoverMutex.Unlock()
+	status := &FailoverStatus{
+		Active:              time.Now().Before(c.failoverUntil),
+		URL:                 fallback,
+		ConsecutiveFailures: c.primaryFailures,
+	}
+	if !c.failoverSince.IsZero() {
+		status.Since = c.failoverSince.UTC().Format(time.RFC3339)
+		status.Until = c.failoverUntil.UTC().Format(time.RFC3339)
+	}
+	return status
+}
@@ -0,0 +1,98 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+const (
+	requestIDHeader   = "X-Request-ID"
+	traceparentHeader = "traceparent"
+)
+
+type (
+	requestIDContextKey   struct{}
+	traceparentContextKey struct{}
+)
+
+// WithRequestID returns a copy of ctx carrying the ID of the client request it
+// serves and the request's traceparent header, if valid, which upstream
+// requests made with ctx pass on (see setOutboundHeaders).
+func WithRequestID(ctx context.Context, id, traceparent string) context.Context {
+	ctx = context.WithValue(ctx, requestIDContextKey{}, id)
+	if validTraceparent(traceparent) {
+		ctx = context.WithValue(ctx, traceparentContextKey{}, traceparent)
+	}
+	return ctx
+}
+
+// traceparentPattern matches a version 00 W3C Trace Context traceparent header.
+var traceparentPattern = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// traceIDPattern matches a W3C Trace Context trace ID.
+var traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// validTraceparent reports whether v is a usable traceparent header; all-zero
+// trace and parent IDs are invalid.
+func validTraceparent(v string) bool {
+	m := traceparentPattern.FindStringSubmatch(v)
+	return m != nil && validTraceID(m[1]) && m[2] != "0000000000000000"
+}
+
+// validTraceID reports whether id can be used as a trace ID.
+func validTraceID(id string) bool {
+	return traceIDPattern.MatchString(id) && id != "00000000000000000000000000000000"
+}
+
+// upstreamUserAgent returns the User-Agent of upstream requests,
+// UPSTREAM_USER_AGENT or the service name. The server sets the former to the
+// service name and its build version unless configured.
+func (c *Client) upstreamUserAgent() string {
+	if ua := c.cfg.Upstream.UserAgent; ua != "" {
+		return ua
+	}
+	return "dataset-catalog-api"
+}
+
+// setOutboundHeaders identifies the service to the upstream operators: it sets
+// the User-Agent and, for requests made on behalf of a client, its request ID
+// as X-Request-ID. Every request carries a traceparent header continuing the
+// client's trace, or else a trace whose ID is the request ID, or a new one for
+// background requests, so both sides can correlate their logs.
+func (c *Client) setOutboundHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.upstreamUserAgent())
+	ctx := req.Context()
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	if id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+	req.Header.Set(traceparentHeader, outboundTraceparent(ctx, id))
+}
+
+// outboundTraceparent returns the traceparent of an upstream request: a new
+// span of the client's trace if it sent one, else of a trace identified by
+// requestID if that is a valid trace ID, else of a new trace.
+func outboundTraceparent(ctx context.Context, requestID string) string {
+	incoming, _ := ctx.Value(traceparentContextKey{}).(string)
+	if m := traceparentPattern.FindStringSubmatch(incoming); m != nil {
+		return "00-" + m[1] + "-" + randomHex(8) + "-" + m[3]
+	}
+	traceID := requestID
+	if !validTraceID(traceID) {
+		traceID = randomHex(16)
+	}
+	return "00-" + traceID + "-" + randomHex(8) + "-00"
+}
+
+// randomHex returns n random bytes, hex-encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,85 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Endpoint labels of the upstream metrics.
+const (
+	endpointList     = "list"
+	endpointDetail   = "detail"
+	endpointMobility = "mobility"
+	endpointOpenAPI  = "openapi"
+	endpointSnapshot = "snapshot"
+	endpointToken    = "token"
+	endpointHealth   = "health"
+)
+
+// upstreamMetrics measure the outbound calls of a Client, by endpoint.
+type upstreamMetrics struct {
+	upstreamDuration    *prometheus.HistogramVec
+	upstreamResponses   *prometheus.CounterVec
+	upstreamInFlight    *prometheus.GaugeVec
+	upstreamConnections *prometheus.CounterVec
+}
+
+func newUpstreamMetrics() upstreamMetrics {
+	return upstreamMetrics{
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "catalog_upstream_request_duration_seconds",
+			Help:    "Time until the upstream API answered with response headers, by endpoint.",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"endpoint"}),
+		upstreamResponses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_responses_total",
+			Help: `Upstream requests by endpoint and status code; "error" when no response was received.`,
+		}, []string{"endpoint", "code"}),
+		upstreamInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "catalog_upstream_in_flight_requests",
+			Help: "Upstream requests waiting for a response, by endpoint.",
+		}, []string{"endpoint"}),
+		upstreamConnections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "catalog_upstream_connections_total",
+			Help: `Connections used by upstream requests, by endpoint, protocol and whether they were reused ("true") or newly opened ("false").`,
+		}, []string{"endpoint", "protocol", "reused"}),
+	}
+}
+
+func (m upstreamMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.upstreamDuration, m.upstreamResponses, m.upstreamInFlight, m.upstreamConnections}
+}
+
+// doUpstream sends req with the shared client, identifying the service with
+// setOutboundHeaders, and records it in the upstream metrics under endpoint,
+// telling the time spent waiting on the upstream API apart from our own.
+func (c *Client) doUpstream(endpoint string, req *http.Request) (*http.Response, error) {
+	inFlight := c.upstreamInFlight.WithLabelValues(endpoint)
+	inFlight.Inc()
+	defer inFlight.Dec()
+	c.setOutboundHeaders(req)
+	// Replayed responses (see recordingRoundTripper) use no connection.
+	var conn *httptrace.GotConnInfo
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) { conn = &info },
+	}))
+	start := time.Now()
+	resp, err := c.upstreamClient().Do(req)
+	c.upstreamDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.upstreamResponses.WithLabelValues(endpoint, "error").Inc()
+		return nil, err
+	}
+	c.upstreamResponses.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+	if conn != nil {
+		c.upstreamConnections.WithLabelValues(endpoint, resp.Proto, strconv.FormatBool(conn.Reused)).Inc()
+	}
+	return resp, nil
+}
@@ -0,0 +1,166 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxWalkPages bounds the pages of a single walk of the catalog, in case the
+// upstream links never end.
+const maxWalkPages = 10000
+
+// errPaginationLoop is returned when the upstream NextPage links lead back to
+// a page already walked, or on past maxWalkPages.
+var errPaginationLoop = errors.New("upstream pagination loops")
+
+// errStopWalk stops fetchPages early without failing the walk.
+var errStopWalk = errors.New("stop walk")
+
+// nextPageKey returns the key of the page following key, whose response is
+// data, as given by the NextPage link of data. If the link matches the URL
+// built from the page number, the usual key is returned, so the page is shared
+// with the other cache users; otherwise the key carries the link. Links of the
+// fallback API are mapped to the MetaData API, links to other hosts are
+// rejected. Without a link, the catalog ends there, unless TotalPages says
+// otherwise for a numbered page. It reports false after the last page.
+func (c *Client) nextPageKey(key pageKey, data *MetaDataPage) (pageKey, bool, error) {
+	if len(data.Items) == 0 {
+		return pageKey{}, false, nil
+	}
+	if data.NextPage == "" {
+		if key.link == "" && key.page < data.TotalPages {
+			return key.withPage(key.page + 1), true, nil
+		}
+		return pageKey{}, false, nil
+	}
+	base, err := url.Parse(key.url())
+	if err != nil {
+		return pageKey{}, false, err
+	}
+	link, err := base.Parse(data.NextPage)
+	if err != nil {
+		return pageKey{}, false, fmt.Errorf("invalid NextPage link %q: %w", data.NextPage, err)
+	}
+	if fallback := c.fallbackURL(); fallback != "" && strings.HasPrefix(link.String(), fallback) {
+		link, _ = url.Parse(metaDataURL + strings.TrimPrefix(link.String(), fallback))
+	}
+	if !sameHost(link.String(), key.source) {
+		return pageKey{}, false, fmt.Errorf("NextPage link %q leaves %s", data.NextPage, key.source)
+	}
+	next := key.withPage(key.page + 1)
+	if page, err := strconv.Atoi(link.Query().Get("pagenumber")); err == nil {
+		next = key.withPage(page)
+	}
+	if !sameURL(link, next.url()) {
+		next.link = link.String()
+	}
+	return next, true, nil
+}
+
+// sameHost reports whether two URLs point to the same host.
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	return err == nil && ua.Host != "" && ua.Host == ub.Host
+}
+
+// sameURL reports whether u is raw up to the order of the query parameters.
+func sameURL(u *url.URL, raw string) bool {
+	v, err := url.Parse(raw)
+	if err != nil || u.Scheme != v.Scheme || u.Host != v.Host || u.Path != v.Path {
+		return false
+	}
+	return maps.EqualFunc(u.Query(), v.Query(), slices.Equal)
+}
+
+// walkPages walks a listing of the upstream API from the page first by
+// following the NextPage links, so changes of the upstream pagination do not
+// break it, and calls fn with each page in order. As long as the links follow
+// the page numbers, the pages up to TotalPages are fetched concurrently by
+// fetchPages and their links checked as they come in; from the first link that
+// does not, the walk continues one page at a time. Links leading back to a
+// page already walked fail with errPaginationLoop.
+func (c *Client) walkPages(ctx context.Context, first pageKey, fetch func(ctx context.Context, key pageKey) (*MetaDataPage, error), fn func(key pageKey, data *MetaDataPage) error) error {
+	visited := make(map[pageKey]bool)
+	visit := func(key pageKey) error {
+		if visited[key] || len(visited) >= maxWalkPages {
+			return fmt.Errorf("%w at page %d", errPaginationLoop, key.page)
+		}
+		visited[key] = true
+		return nil
+	}
+
+	key := first
+	for {
+		if err := visit(key); err != nil {
+			return err
+		}
+		data, err := fetch(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := fn(key, data); err != nil {
+			return err
+		}
+		next, ok, err := c.nextPageKey(key, data)
+		if err != nil || !ok {
+			return err
+		}
+		if next.link == "" && next.page <= data.TotalPages {
+			next, ok, err = c.walkNumbered(ctx, next, data.TotalPages, visit, fetch, fn)
+			if err != nil || !ok {
+				return err
+			}
+		}
+		key = next
+	}
+}
+
+// walkNumbered fetches the pages from to last concurrently and calls fn with
+// each of them in order, for as long as every page is the one the NextPage
+// link of the page before points to. It returns the key of the page to
+// continue with, or false after the last page.
+func (c *Client) walkNumbered(ctx context.Context, from pageKey, last int, visit func(pageKey) error, fetch func(ctx context.Context, key pageKey) (*MetaDataPage, error), fn func(key pageKey, data *MetaDataPage) error) (pageKey, bool, error) {
+	expected, more := from, true
+	fetchPage := func(ctx context.Context, page int) (*MetaDataPage, error) {
+		return fetch(ctx, from.withPage(page))
+	}
+	err := fetchPages(ctx, c.fetchWorkers(), from.page, last, fetchPage, func(page int, data *MetaDataPage) error {
+		key := from.withPage(page)
+		if key != expected {
+			return errStopWalk
+		}
+		if err := visit(key); err != nil {
+			return err
+		}
+		if err := fn(key, data); err != nil {
+			return err
+		}
+		next, ok, err := c.nextPageKey(key, data)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			more = false
+			return errStopWalk
+		}
+		expected = next
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopWalk) {
+		return pageKey{}, false, err
+	}
+	return expected, more, nil
+}
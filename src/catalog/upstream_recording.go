@@ -0,0 +1,126 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// recording is an upstream response saved by recordingTransport.
+type recording struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// recordedHeaders are the response headers kept in recordings.
+var recordedHeaders = []string{"Content-Type", "Retry-After", "Last-Modified", "Etag"}
+
+// unsafeFileChars matches the characters replaced in recording file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// recordingFile returns the file the response to a request is recorded in:
+// the host and path for readability, and a hash of the method and full URL
+// to tell queries apart.
+func recordingFile(dir string, req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	name := unsafeFileChars.ReplaceAllString(req.URL.Host+req.URL.Path, "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return filepath.Join(dir, name+"-"+hex.EncodeToString(sum[:6])+".json")
+}
+
+// recordingTransport saves the responses to GET requests in dir, overwriting
+// earlier recordings of the same request. Other requests, such as token
+// requests, are sent without being recorded, so no credentials end up on disk.
+type recordingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec := recording{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: http.Header{}, Body: string(body)}
+	for _, h := range recordedHeaders {
+		if v := resp.Header.Values(h); len(v) > 0 {
+			rec.Header[h] = v
+		}
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("recording upstream response: %w", err)
+	}
+	if err := os.WriteFile(recordingFile(t.dir, req), data, 0o644); err != nil {
+		return nil, fmt.Errorf("recording upstream response: %w", err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers requests with the responses recordingTransport
+// saved in dir, without any network access. Requests without a recording fail.
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	data, err := os.ReadFile(recordingFile(t.dir, req))
+	if err != nil {
+		return nil, fmt.Errorf("no recording of %s %s: %w", req.Method, req.URL, err)
+	}
+	var rec recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("reading recording of %s %s: %w", req.Method, req.URL, err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(rec.Body))),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// recordingRoundTripper wraps next to record upstream responses in
+// UPSTREAM_RECORD_DIR, or replaces it to replay them from UPSTREAM_REPLAY_DIR.
+func (c *Client) recordingRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if dir := c.cfg.Upstream.ReplayDir; dir != "" {
+		return replayTransport{dir: dir}
+	}
+	if dir := c.cfg.Upstream.RecordDir; dir != "" {
+		return recordingTransport{next: next, dir: dir}
+	}
+	return next
+}
@@ -0,0 +1,66 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package catalog
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CheckUpstreamTLS reports an invalid upstream TLS configuration, so it fails
+// at startup rather than on the first upstream request.
+func (c *Client) CheckUpstreamTLS() error {
+	_, err := c.upstreamTLSConfig()
+	return err
+}
+
+// upstreamTLSConfig returns the TLS configuration of upstream connections, for
+// upstreams fronted by an internal gateway:
+//   - UPSTREAM_CA_FILE, a PEM file of root CAs trusted in addition to the
+//     system ones;
+//   - UPSTREAM_TLS_MIN_VERSION, the lowest accepted TLS version, 1.2
+//     (default) or 1.3;
+//   - UPSTREAM_CLIENT_CERT and UPSTREAM_CLIENT_KEY, PEM files of a client
+//     certificate and its key, presented when the server asks for one.
+func (c *Client) upstreamTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch v := c.cfg.Upstream.TLSMinVersion; v {
+	case "", "1.2":
+	case "1.3":
+		config.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported UPSTREAM_TLS_MIN_VERSION %q", v)
+	}
+
+	if caFile := c.cfg.Upstream.CAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	certFile, keyFile := c.cfg.Upstream.ClientCert, c.cfg.Upstream.ClientKey
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("UPSTREAM_CLIENT_CERT and UPSTREAM_CLIENT_KEY must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,146 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"opendatahub.com/dataset-catalog-api/handlers"
+)
+
+// usage prints the commands of the binary.
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: dataset-catalog-api [command] [flags]
+
+Commands:
+  serve      run the HTTP API and the gRPC CatalogService (default)
+  dump       write a full catalog export to a file or stdout
+  generate   write the catalog as a static site into a directory
+  validate   check the outputs of every dataset against their schemas
+  sync       sync the upstream catalog into the caches once
+  bench      measure the latency of a mix of requests
+
+Run "dataset-catalog-api <command> -h" for the flags of a command. All
+commands are configured by the same environment variables as the server.
+`)
+}
+
+// commandContext returns a context cancelled on SIGINT or SIGTERM.
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// dumpCommand writes a full catalog export, like the dump endpoints, for
+// publishing the catalog from cron jobs or CI pipelines.
+func dumpCommand(a *app, args []string) int {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	format := flags.String("format", "dcat", "export format: "+strings.Join(handlers.DumpFormats, ", "))
+	output := flags.String("o", "-", "output file, - for stdout")
+	deprecated := flags.String("deprecated", "exclude", "deprecated datasets: include, exclude or only")
+	flags.Parse(args)
+	if !slices.Contains(handlers.DumpFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Unsupported format %q\n", *format)
+		return 2
+	}
+	setupCatalog(a)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Printf("Error creating %s: %v", *output, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	count, err := a.server.WriteDump(ctx, w, *format, *deprecated)
+	if err != nil {
+		log.Printf("Error writing %s dump: %v", *format, err)
+		return 1
+	}
+	log.Printf("Wrote %d datasets as %s", count, *format)
+	return 0
+}
+
+// generateCommand writes the catalog as a static site.
+func generateCommand(a *app, args []string) int {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	output := flags.String("o", "site", "output directory")
+	baseURL := flags.String("base-url", "", "URL the site will be served from (default BASE_URL)")
+	deprecated := flags.String("deprecated", "exclude", "deprecated datasets: include, exclude or only")
+	flags.Parse(args)
+	setupCatalog(a)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if *baseURL == "" {
+		*baseURL = a.cfg.Publisher.BaseURL
+	}
+	if !strings.HasSuffix(*baseURL, "/") {
+		*baseURL += "/"
+	}
+	count, err := a.server.GenerateSite(ctx, *output, *baseURL, *deprecated)
+	if err != nil {
+		log.Printf("Error generating the site: %v", err)
+		return 1
+	}
+	log.Printf("Generated %d datasets into %s", count, *output)
+	return 0
+}
+
+// validateCommand checks the DCAT and ODPS outputs of every dataset against
+// the fields their schemas require, failing if any is missing.
+func validateCommand(a *app, args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Parse(args)
+	setupCatalog(a)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	checked, problems, err := a.server.ValidateCatalog(ctx, os.Stdout)
+	if err != nil {
+		log.Printf("Error validating the catalog: %v", err)
+		return 1
+	}
+	log.Printf("Validated %d datasets: %d problems", checked, problems)
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// syncCommand syncs the whole upstream catalog into the caches once, warming
+// the persistent cache, memcached or the cache state file for the server.
+func syncCommand(a *app, args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	flags.Parse(args)
+	setupCatalog(a)
+
+	pages, datasets, err := a.catalog.SyncCatalog()
+	if err != nil {
+		log.Printf("Sync failed after %d pages: %v", pages, err)
+		return 1
+	}
+	log.Printf("Synced %d pages, %d datasets", pages, datasets)
+	if stateFile := a.cfg.Cache.StateFile; stateFile != "" {
+		if err := a.catalog.SaveCacheState(stateFile); err != nil {
+			log.Printf("Error saving cache state to %s: %v", stateFile, err)
+			return 1
+		}
+	}
+	a.catalog.StopBackground(context.Background())
+	return 0
+}
@@ -0,0 +1,429 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package config holds the configuration of the catalog service. A Config is
+// built explicitly, from the defaults (Default) or from environment variables
+// (FromEnv, Parse), and passed to the packages that need it; importing a
+// package of the service never reads the environment or a .env file.
+package config
+
+import (
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// Config is the configuration of the service. The environment variable
+// setting each field is named in its comment; see the README for details.
+type Config struct {
+	// Publisher is the default publisher: BASE_URL, PUBLISHER_* and
+	// CATALOG_TITLE_{LANG} and CATALOG_DESCRIPTION_{LANG}.
+	Publisher transformers.Publisher
+
+	Server   Server
+	Cache    Cache
+	Upstream Upstream
+
+	// Files configuring the outputs, ignored when empty.
+	PublisherProfilesFile string // PUBLISHER_PROFILES_FILE
+	ODPSDefaultsFile      string // ODPS_DEFAULTS_FILE
+	LicensesFile          string // LICENSES_FILE
+	DatasetOverridesFile  string // DATASET_OVERRIDES_FILE
+
+	// OutputPostProcess are the post-processing steps of the outputs
+	// (OUTPUT_POSTPROCESS).
+	OutputPostProcess []string
+
+	// SyncSchedule is the cron schedule of the catalog sync (SYNC_SCHEDULE).
+	SyncSchedule string
+
+	// Offline serves fixtures instead of the upstream API (OFFLINE_MODE),
+	// those in OfflineFixtures (OFFLINE_FIXTURES) or the bundled ones.
+	Offline         bool
+	OfflineFixtures string
+}
+
+// Server configures the HTTP and gRPC servers.
+type Server struct {
+	GinMode         string        // GIN_MODE, default release
+	GRPCPort        string        // GRPC_PORT
+	TLSCertFile     string        // TLS_CERT_FILE
+	TLSKeyFile      string        // TLS_KEY_FILE
+	ShutdownDelay   time.Duration // SHUTDOWN_DELAY
+	ShutdownTimeout time.Duration // SHUTDOWN_TIMEOUT
+	TemplatesDir    string        // TEMPLATES_DIR
+	AdminToken      string        // ADMIN_TOKEN
+	MetricsAddr     string        // METRICS_ADDR
+	PprofEnabled    bool          // PPROF_ENABLED
+	TrustedProxies  []string      // TRUSTED_PROXIES
+	RobotsAllow     []string      // ROBOTS_ALLOW
+	RobotsDisallow  []string      // ROBOTS_DISALLOW
+	DisabledRoutes  []string      // DISABLED_ROUTES
+	EnabledRoutes   []string      // ENABLED_ROUTES
+	DisabledFormats []string      // DISABLED_FORMATS
+	// CacheControl maps route paths, or patterns ending in "*", to the
+	// Cache-Control policy replacing their default (CACHE_CONTROL).
+	CacheControl map[string]string
+}
+
+// Cache configures the caches.
+type Cache struct {
+	Backend             string        // CACHE_BACKEND
+	MemcachedServers    []string      // MEMCACHED_SERVERS
+	File                string        // CACHE_FILE
+	StateFile           string        // CACHE_STATE_FILE
+	Seed                string        // CACHE_SEED
+	MaxStaleness        time.Duration // CACHE_MAX_STALENESS
+	JanitorInterval     time.Duration // CACHE_JANITOR_INTERVAL
+	MemoryBudget        int64         // CACHE_MEMORY_BUDGET
+	InvalidationRedis   string        // INVALIDATION_REDIS_URL
+	InvalidationChannel string        // INVALIDATION_CHANNEL
+}
+
+// Upstream configures the access to the upstream APIs.
+type Upstream struct {
+	PageSize            int           // UPSTREAM_PAGE_SIZE
+	FetchWorkers        int           // FETCH_WORKERS
+	PrefetchDepth       int           // UPSTREAM_PREFETCH_DEPTH
+	ConnectTimeout      time.Duration // UPSTREAM_CONNECT_TIMEOUT
+	Timeout             time.Duration // UPSTREAM_TIMEOUT
+	KeepAlive           time.Duration // UPSTREAM_KEEPALIVE
+	MaxIdleConns        int           // UPSTREAM_MAX_IDLE_CONNS
+	MaxIdleConnsPerHost int           // UPSTREAM_MAX_IDLE_CONNS_PER_HOST
+	MaxConnsPerHost     int           // UPSTREAM_MAX_CONNS_PER_HOST
+	IdleConnTimeout     time.Duration // UPSTREAM_IDLE_CONN_TIMEOUT
+	HTTP2               bool          // UPSTREAM_HTTP2
+	HTTP2PingInterval   time.Duration // UPSTREAM_HTTP2_PING_INTERVAL
+	Proxy               string        // UPSTREAM_PROXY
+	RPS                 float64       // UPSTREAM_RPS
+	Burst               int           // UPSTREAM_BURST
+	MaxBackoff          time.Duration // UPSTREAM_MAX_BACKOFF
+	FallbackURL         string        // UPSTREAM_FALLBACK_URL
+	FailoverThreshold   int           // UPSTREAM_FAILOVER_THRESHOLD
+	FailoverDuration    time.Duration // UPSTREAM_FAILOVER_DURATION
+	UserAgent           string        // UPSTREAM_USER_AGENT
+	CAFile              string        // UPSTREAM_CA_FILE
+	TLSMinVersion       string        // UPSTREAM_TLS_MIN_VERSION
+	ClientCert          string        // UPSTREAM_CLIENT_CERT
+	ClientKey           string        // UPSTREAM_CLIENT_KEY
+	Token               string        // UPSTREAM_TOKEN
+	TokenURL            string        // UPSTREAM_TOKEN_URL
+	ClientID            string        // UPSTREAM_CLIENT_ID
+	ClientSecret        string        // UPSTREAM_CLIENT_SECRET
+	RecordDir           string        // UPSTREAM_RECORD_DIR
+	ReplayDir           string        // UPSTREAM_REPLAY_DIR
+	MobilityAPIURL      string        // MOBILITY_API_URL
+}
+
+// Default returns the configuration used when no environment variable is set.
+func Default() *Config {
+	return &Config{
+		Publisher: transformers.DefaultPublisher,
+		Server: Server{
+			GinMode:         "release",
+			GRPCPort:        "9878",
+			ShutdownTimeout: 10 * time.Second,
+			RobotsAllow:     []string{"/datasets/", "/odps30/", "/odps31/"},
+			RobotsDisallow:  []string{"/admin/", "/debug/", "/metrics"},
+		},
+		Cache: Cache{
+			MemcachedServers:    []string{"localhost:11211"},
+			MaxStaleness:        time.Hour,
+			JanitorInterval:     time.Minute,
+			InvalidationChannel: "dataset-catalog:invalidate",
+		},
+		Upstream: Upstream{
+			PageSize:            100,
+			FetchWorkers:        4,
+			PrefetchDepth:       1,
+			ConnectTimeout:      5 * time.Second,
+			Timeout:             30 * time.Second,
+			KeepAlive:           30 * time.Second,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			HTTP2:               true,
+			HTTP2PingInterval:   30 * time.Second,
+			RPS:                 10,
+			Burst:               20,
+			MaxBackoff:          10 * time.Minute,
+			FailoverThreshold:   3,
+			FailoverDuration:    5 * time.Minute,
+		},
+	}
+}
+
+// FromEnv returns the configuration set by the environment of the process.
+func FromEnv() *Config {
+	return Parse(os.Environ())
+}
+
+// Parse returns the configuration set by environ, a list of "NAME=value"
+// entries as returned by os.Environ. Unset and empty variables keep their
+// default; invalid values are logged and keep it as well.
+func Parse(environ []string) *Config {
+	e := env{}
+	for _, kv := range environ {
+		if name, v, ok := strings.Cut(kv, "="); ok {
+			e[name] = v
+		}
+	}
+	c := Default()
+
+	c.Publisher.BaseURL = e.str("BASE_URL", c.Publisher.BaseURL)
+	for name, field := range publisherFields(&c.Publisher) {
+		*field = e.str(name, *field)
+	}
+	for name, v := range e {
+		if v == "" {
+			continue
+		}
+		if lang, ok := strings.CutPrefix(name, "CATALOG_TITLE_"); ok && lang != "" {
+			if c.Publisher.Title == nil {
+				c.Publisher.Title = map[string]string{}
+			}
+			c.Publisher.Title[strings.ToLower(lang)] = v
+		}
+		if lang, ok := strings.CutPrefix(name, "CATALOG_DESCRIPTION_"); ok && lang != "" {
+			if c.Publisher.Description == nil {
+				c.Publisher.Description = map[string]string{}
+			}
+			c.Publisher.Description[strings.ToLower(lang)] = v
+		}
+	}
+
+	s := &c.Server
+	s.GinMode = e.str("GIN_MODE", s.GinMode)
+	s.GRPCPort = e.str("GRPC_PORT", s.GRPCPort)
+	s.TLSCertFile = e.str("TLS_CERT_FILE", s.TLSCertFile)
+	s.TLSKeyFile = e.str("TLS_KEY_FILE", s.TLSKeyFile)
+	s.ShutdownDelay = e.duration("SHUTDOWN_DELAY", s.ShutdownDelay)
+	s.ShutdownTimeout = e.duration("SHUTDOWN_TIMEOUT", s.ShutdownTimeout)
+	s.TemplatesDir = e.str("TEMPLATES_DIR", s.TemplatesDir)
+	s.AdminToken = e.str("ADMIN_TOKEN", s.AdminToken)
+	s.MetricsAddr = e.str("METRICS_ADDR", s.MetricsAddr)
+	s.PprofEnabled = e.bool("PPROF_ENABLED", s.PprofEnabled)
+	s.TrustedProxies = e.list("TRUSTED_PROXIES", s.TrustedProxies)
+	s.RobotsAllow = e.list("ROBOTS_ALLOW", s.RobotsAllow)
+	s.RobotsDisallow = e.list("ROBOTS_DISALLOW", s.RobotsDisallow)
+	s.DisabledRoutes = e.list("DISABLED_ROUTES", s.DisabledRoutes)
+	s.EnabledRoutes = e.list("ENABLED_ROUTES", s.EnabledRoutes)
+	s.DisabledFormats = e.list("DISABLED_FORMATS", s.DisabledFormats)
+	s.CacheControl = e.mapping("CACHE_CONTROL", s.CacheControl)
+
+	ca := &c.Cache
+	ca.Backend = e.str("CACHE_BACKEND", ca.Backend)
+	ca.MemcachedServers = e.list("MEMCACHED_SERVERS", ca.MemcachedServers)
+	ca.File = e.str("CACHE_FILE", ca.File)
+	ca.StateFile = e.str("CACHE_STATE_FILE", ca.StateFile)
+	ca.Seed = e.str("CACHE_SEED", ca.Seed)
+	ca.MaxStaleness = e.duration("CACHE_MAX_STALENESS", ca.MaxStaleness)
+	ca.JanitorInterval = e.duration("CACHE_JANITOR_INTERVAL", ca.JanitorInterval)
+	ca.MemoryBudget = max(e.bytes("CACHE_MEMORY_BUDGET", ca.MemoryBudget), 0)
+	ca.InvalidationRedis = e.str("INVALIDATION_REDIS_URL", ca.InvalidationRedis)
+	ca.InvalidationChannel = e.str("INVALIDATION_CHANNEL", ca.InvalidationChannel)
+
+	u := &c.Upstream
+	u.PageSize = max(e.int("UPSTREAM_PAGE_SIZE", u.PageSize), 1)
+	u.FetchWorkers = max(e.int("FETCH_WORKERS", u.FetchWorkers), 1)
+	u.PrefetchDepth = max(e.int("UPSTREAM_PREFETCH_DEPTH", u.PrefetchDepth), 0)
+	u.ConnectTimeout = e.duration("UPSTREAM_CONNECT_TIMEOUT", u.ConnectTimeout)
+	u.Timeout = e.duration("UPSTREAM_TIMEOUT", u.Timeout)
+	u.KeepAlive = e.duration("UPSTREAM_KEEPALIVE", u.KeepAlive)
+	u.MaxIdleConns = e.int("UPSTREAM_MAX_IDLE_CONNS", u.MaxIdleConns)
+	// The idle pool keeps a connection per fetch worker by default.
+	u.MaxIdleConnsPerHost = e.int("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", max(u.MaxIdleConnsPerHost, u.FetchWorkers))
+	u.MaxConnsPerHost = e.int("UPSTREAM_MAX_CONNS_PER_HOST", u.MaxConnsPerHost)
+	u.IdleConnTimeout = e.duration("UPSTREAM_IDLE_CONN_TIMEOUT", u.IdleConnTimeout)
+	u.HTTP2 = e.bool("UPSTREAM_HTTP2", u.HTTP2)
+	u.HTTP2PingInterval = e.duration("UPSTREAM_HTTP2_PING_INTERVAL", u.HTTP2PingInterval)
+	u.Proxy = e.str("UPSTREAM_PROXY", u.Proxy)
+	u.RPS = e.float("UPSTREAM_RPS", u.RPS)
+	u.Burst = max(e.int("UPSTREAM_BURST", u.Burst), 1)
+	u.MaxBackoff = e.duration("UPSTREAM_MAX_BACKOFF", u.MaxBackoff)
+	u.FallbackURL = strings.TrimSuffix(e.str("UPSTREAM_FALLBACK_URL", u.FallbackURL), "/")
+	u.FailoverThreshold = max(e.int("UPSTREAM_FAILOVER_THRESHOLD", u.FailoverThreshold), 1)
+	u.FailoverDuration = e.duration("UPSTREAM_FAILOVER_DURATION", u.FailoverDuration)
+	u.UserAgent = e.str("UPSTREAM_USER_AGENT", u.UserAgent)
+	u.CAFile = e.str("UPSTREAM_CA_FILE", u.CAFile)
+	u.TLSMinVersion = e.str("UPSTREAM_TLS_MIN_VERSION", u.TLSMinVersion)
+	u.ClientCert = e.str("UPSTREAM_CLIENT_CERT", u.ClientCert)
+	u.ClientKey = e.str("UPSTREAM_CLIENT_KEY", u.ClientKey)
+	u.Token = e.str("UPSTREAM_TOKEN", u.Token)
+	u.TokenURL = e.str("UPSTREAM_TOKEN_URL", u.TokenURL)
+	u.ClientID = e.str("UPSTREAM_CLIENT_ID", u.ClientID)
+	u.ClientSecret = e.str("UPSTREAM_CLIENT_SECRET", u.ClientSecret)
+	u.RecordDir = e.str("UPSTREAM_RECORD_DIR", u.RecordDir)
+	u.ReplayDir = e.str("UPSTREAM_REPLAY_DIR", u.ReplayDir)
+	u.MobilityAPIURL = strings.TrimSuffix(e.str("MOBILITY_API_URL", u.MobilityAPIURL), "/")
+
+	c.PublisherProfilesFile = e.str("PUBLISHER_PROFILES_FILE", c.PublisherProfilesFile)
+	c.ODPSDefaultsFile = e.str("ODPS_DEFAULTS_FILE", c.ODPSDefaultsFile)
+	c.LicensesFile = e.str("LICENSES_FILE", c.LicensesFile)
+	c.DatasetOverridesFile = e.str("DATASET_OVERRIDES_FILE", c.DatasetOverridesFile)
+	c.OutputPostProcess = e.list("OUTPUT_POSTPROCESS", c.OutputPostProcess)
+	c.SyncSchedule = e.str("SYNC_SCHEDULE", c.SyncSchedule)
+	c.Offline = e.bool("OFFLINE_MODE", c.Offline)
+	c.OfflineFixtures = e.str("OFFLINE_FIXTURES", c.OfflineFixtures)
+	return c
+}
+
+// publisherFields maps the environment variables overriding the publisher
+// metadata to the fields of p they set.
+func publisherFields(p *transformers.Publisher) map[string]*string {
+	return map[string]*string{
+		"PUBLISHER_NAME":            &p.Name,
+		"PUBLISHER_URL":             &p.URL,
+		"PUBLISHER_SLOGAN":          &p.Slogan,
+		"PUBLISHER_CONTACT_NAME":    &p.ContactName,
+		"PUBLISHER_CONTACT_EMAIL":   &p.ContactEmail,
+		"PUBLISHER_CONTACT_PHONE":   &p.ContactPhone,
+		"PUBLISHER_CONTACT_WEBSITE": &p.ContactWebsite,
+		"PUBLISHER_STREET_ADDRESS":  &p.StreetAddress,
+		"PUBLISHER_POSTAL_CODE":     &p.PostalCode,
+		"PUBLISHER_LOCALITY":        &p.Locality,
+		"PUBLISHER_REGION":          &p.Region,
+		"PUBLISHER_COUNTRY":         &p.Country,
+		"PUBLISHER_VAT_ID":          &p.VatID,
+		"PUBLISHER_TAX_ID":          &p.TaxID,
+	}
+}
+
+// env holds environment variables by name.
+type env map[string]string
+
+func (e env) str(name, def string) string {
+	if v := e[name]; v != "" {
+		return v
+	}
+	return def
+}
+
+// list returns the comma-separated items of name. Unlike the other types, a
+// variable set to the empty string yields an empty list.
+func (e env) list(name string, def []string) []string {
+	v, ok := e[name]
+	if !ok {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// mapping returns the "key=value" entries of name, separated by semicolons, as
+// values such as Cache-Control policies contain commas. Like list, a variable
+// set to the empty string yields an empty map.
+func (e env) mapping(name string, def map[string]string) map[string]string {
+	v, ok := e[name]
+	if !ok {
+		return def
+	}
+	out := map[string]string{}
+	for _, entry := range strings.Split(v, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			log.Printf("Invalid %s entry %q, ignored", name, entry)
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
+
+func (e env) duration(name string, def time.Duration) time.Duration {
+	v := e[name]
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
+
+func (e env) int(name string, def int) int {
+	v := e[name]
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %d", name, v, def)
+		return def
+	}
+	return n
+}
+
+// byteUnits are the units of the sizes accepted by bytes, by suffix.
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"B", 1},
+}
+
+// bytes returns the size in bytes of name, given as an integer number of
+// bytes or with a unit, e.g. 64MiB or 500MB.
+func (e env) bytes(name string, def int64) int64 {
+	v := e[name]
+	if v == "" {
+		return def
+	}
+	number, unit := strings.TrimSpace(v), int64(1)
+	for _, u := range byteUnits {
+		if trimmed, found := strings.CutSuffix(number, u.suffix); found {
+			number, unit = strings.TrimSpace(trimmed), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n > math.MaxInt64/unit {
+		log.Printf("Invalid %s %q, using %d", name, v, def)
+		return def
+	}
+	return n * unit
+}
+
+func (e env) float(name string, def float64) float64 {
+	v := e[name]
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %g", name, v, def)
+		return def
+	}
+	return f
+}
+
+func (e env) bool(name string, def bool) bool {
+	v := e[name]
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Invalid %s %q, using %t", name, v, def)
+		return def
+	}
+	return b
+}
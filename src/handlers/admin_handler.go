@@ -0,0 +1,61 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware protects the /admin endpoints with the bearer token
+// configured in ADMIN_TOKEN. Without a token the endpoints are disabled.
+func (s *Server) AdminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := s.cfg.Server.AdminToken
+		if token == "" {
+			problem(c, http.StatusForbidden, "Admin endpoints are disabled")
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			problem(c, http.StatusUnauthorized, "Missing or invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CacheFlushGinHandler serves POST /admin/cache/flush, dropping cached upstream
+// data so it is fetched again on the next request. ?page={n} limits the flush
+// to one upstream page and ?id={uuid} to one dataset; without either, every
+// cache is flushed. The flush is also published to the other instances.
+func (s *Server) CacheFlushGinHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	id := c.Query("id")
+	flushed := s.catalog.Flush(page, id)
+	s.catalog.PublishInvalidation(page, id)
+	c.JSON(http.StatusOK, gin.H{"flushed": flushed})
+}
+
+// CacheStatsGinHandler serves GET /admin/cache/stats, the hit, miss, eviction
+// and expiry counts and entry ages of every cache since the service started.
+func (s *Server) CacheStatsGinHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, s.catalog.CacheStats())
+}
+
+// SyncStatusGinHandler serves GET /admin/sync, the state of the scheduled
+// catalog synchronization.
+func (s *Server) SyncStatusGinHandler(c *gin.Context) {
+	status, enabled := s.catalog.CurrentSyncStatus()
+	if !enabled {
+		problem(c, http.StatusNotFound, "Scheduled sync is disabled")
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
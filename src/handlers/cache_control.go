@@ -0,0 +1,62 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// publicMaxAge returns a Cache-Control policy letting shared caches keep a
+// response for d.
+func publicMaxAge(d time.Duration) string {
+	return fmt.Sprintf("public, max-age=%d", int(d.Seconds()))
+}
+
+// cacheControlPolicy returns the Cache-Control policy of the route at path:
+// the one CACHE_CONTROL sets for it, or else policy, the default of the route.
+// A path set exactly takes precedence over the patterns ending in "*"
+// matching it, and longer patterns over shorter ones.
+func (s *Server) cacheControlPolicy(path, policy string) string {
+	longest := -1
+	for pattern, configured := range s.cfg.Server.CacheControl {
+		if pattern == path {
+			return configured
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			longest, policy = len(prefix), configured
+		}
+	}
+	return policy
+}
+
+// cacheControlMiddleware sets the Cache-Control header to policy, or to the
+// cache TTL when policy is empty, and declares the request headers responses
+// vary on, so CDNs and reverse proxies cache them correctly.
+func cacheControlMiddleware(policy string) gin.HandlerFunc {
+	if policy == "" {
+		policy = publicMaxAge(catalog.CacheTTL)
+	}
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", policy)
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// alignMaxAge reports the age of a response cached at created, or about to be,
+// and sets a default Cache-Control policy to its lifetime, so shared caches
+// keep it until it expires in the response cache. Custom policies, including
+// those set in CACHE_CONTROL, are left alone.
+func alignMaxAge(c *gin.Context, created, expiration time.Time) {
+	c.Header("Age", strconv.Itoa(int(time.Since(created).Seconds())))
+	if c.Writer.Header().Get("Cache-Control") == publicMaxAge(catalog.CacheTTL) {
+		c.Header("Cache-Control", publicMaxAge(expiration.Sub(created)))
+	}
+}
@@ -0,0 +1,122 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// DumpFormats are the formats WriteDump writes the catalog in: a DCAT JSON-LD
+// catalog, DCAT as Turtle, one upstream dataset per line, and a YAML stream
+// of ODPS 3.1 documents.
+var DumpFormats = []string{"dcat", "ttl", "ndjson", "odps31"}
+
+// WriteDump writes the complete catalog to w in format, one of DumpFormats,
+// with the default publisher, as the corresponding dump endpoints serve it.
+// deprecated is the ?deprecated= policy. It returns the number of datasets
+// written.
+func (s *Server) WriteDump(ctx context.Context, w io.Writer, format, deprecated string) (int, error) {
+	p := s.cfg.Publisher
+	lang := transformers.DefaultLanguage
+	bw := bufio.NewWriter(w)
+	count := 0
+	// each calls fn with the datasets of every upstream page.
+	each := func(fn func(datasets []transformers.Dataset) error) error {
+		return s.catalog.ForEachPage(ctx, func(items []transformers.Dataset) error {
+			datasets := catalog.FilterDeprecated(deprecated, items)
+			if err := fn(datasets); err != nil {
+				return err
+			}
+			count += len(datasets)
+			return nil
+		})
+	}
+
+	var err error
+	switch format {
+	case "dcat":
+		var header []byte
+		var root interface{}
+		root, err = s.postProcess(ctx, transformers.DCATCatalog(p))
+		if err != nil {
+			return 0, err
+		}
+		header, err = json.Marshal(root)
+		if err != nil {
+			return 0, err
+		}
+		bw.Write(openDatasetList(header))
+		enc := json.NewEncoder(bw)
+		err = each(func(datasets []transformers.Dataset) error {
+			docs := s.catalog.DCATDatasets(p, datasets, lang)
+			for i, doc := range docs {
+				if count+i > 0 {
+					bw.WriteString(",")
+				}
+				processed, err := s.postProcess(ctx, doc)
+				if err != nil {
+					return err
+				}
+				if err := enc.Encode(processed); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		bw.WriteString("]}\n")
+	case "ttl":
+		var datasets []transformers.Dataset
+		err = each(func(page []transformers.Dataset) error {
+			datasets = append(datasets, page...)
+			return nil
+		})
+		if err == nil {
+			var ttl string
+			ttl, err = transformers.ToTurtle(s.catalog.DCATCatalog(p, datasets, lang))
+			bw.WriteString(ttl)
+		}
+	case "ndjson":
+		enc := json.NewEncoder(bw)
+		err = each(func(datasets []transformers.Dataset) error {
+			for _, ds := range datasets {
+				if err := enc.Encode(ds); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	case "odps31":
+		enc := yaml.NewEncoder(bw)
+		err = each(func(datasets []transformers.Dataset) error {
+			docs := s.catalog.ODPS31Documents(p, datasets, lang)
+			for _, doc := range docs {
+				processed, err := s.postProcess(ctx, doc)
+				if err != nil {
+					return err
+				}
+				if err := enc.Encode(processed); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	default:
+		return 0, fmt.Errorf("unsupported dump format %q", format)
+	}
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
@@ -4,225 +4,139 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
+	"opendatahub.com/dataset-catalog-api/catalog"
 	"opendatahub.com/dataset-catalog-api/transformers"
 )
 
-// ConvertDatasets maps a slice of handlers.Dataset (with all properties)
-// into a slice of transformers.Dataset.
-func ConvertDatasets(d []transformers.Dataset) []transformers.Dataset {
-	var out []transformers.Dataset
-	for _, ds := range d {
-		out = append(out, transformers.Dataset{
-			ID:             ds.ID,
-			Self:           ds.Self,
-			Type:           ds.Type,
-			Meta:           transformers.MetaData(ds.Meta),
-			ApiUrl:         ds.ApiUrl,
-			Output:         ds.Output,
-			ApiType:        ds.ApiType,
-			BaseUrl:        ds.BaseUrl,
-			ODHTags:        ds.ODHTags,
-			OdhType:        ds.OdhType,
-			Sources:        ds.Sources,
-			Category:       ds.Category,
-			ApiAccess:      ds.ApiAccess,
-			ApiFilter:      ds.ApiFilter,
-			Dataspace:      ds.Dataspace,
-			OdhTagIds:      ds.OdhTagIds,
-			PathParam:      ds.PathParam,
-			Shortname:      ds.Shortname,
-			Deprecated:     ds.Deprecated,
-			LastChange:     ds.LastChange,
-			SwaggerUrl:     ds.SwaggerUrl,
-			FirstImport:    ds.FirstImport,
-			LicenseInfo:    transformers.LicenseInfo(ds.LicenseInfo),
-			PublishedOn:    ds.PublishedOn,
-			RecordCount:    ds.RecordCount,
-			DataProvider:   ds.DataProvider,
-			ImageGallery:   convertImageGallery(ds.ImageGallery),
-			ApiDescription: ds.ApiDescription,
-		})
-	}
-	return out
-}
+const defaultPageSize = 10
 
-// convertImageGallery maps a slice of handlers.ImageGalleryItem to a slice of transformers.ImageGalleryItem.
-func convertImageGallery(src []transformers.ImageGalleryItem) []transformers.ImageGalleryItem {
-	var out []transformers.ImageGalleryItem
-	for _, item := range src {
-		out = append(out, transformers.ImageGalleryItem{
-			Width:         item.Width,
-			Height:        item.Height,
-			License:       item.License,
-			ValidTo:       item.ValidTo,
-			ImageUrl:      item.ImageUrl,
-			CopyRight:     item.CopyRight,
-			ImageDesc:     item.ImageDesc,
-			ImageName:     item.ImageName,
-			ImageTags:     item.ImageTags,
-			ValidFrom:     item.ValidFrom,
-			ImageTitle:    item.ImageTitle,
-			ImageSource:   item.ImageSource,
-			IsInGallery:   item.IsInGallery,
-			ImageAltText:  item.ImageAltText,
-			ListPosition:  item.ListPosition,
-			LicenseHolder: item.LicenseHolder,
-		})
+// getPageNumber extracts the "page" query parameter from the request (default=1).
+func getPageNumber(r *http.Request) int {
+	pageStr := r.URL.Query().Get("page")
+	if pageStr == "" {
+		return 1
+	}
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		return 1
 	}
-	return out
+	return page
 }
 
-const pageSize = 10
-
-type cacheItem struct {
-	data       []transformers.Dataset
-	expiration time.Time
+// paginationLinks builds self/first/last/prev/next URLs for a paginated listing
+// at path, keeping the request's other query parameters. prev and next are nil
+// on the first and last page.
+func (s *Server) paginationLinks(r *http.Request, path string, page, totalPages int) map[string]interface{} {
+	link := func(p int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(p))
+		return s.publisherProfileFrom(r.Context()).Publisher.BaseURL + path + "?" + query.Encode()
+	}
+	links := map[string]interface{}{
+		"self":  link(page),
+		"first": link(1),
+		"last":  link(totalPages),
+		"prev":  nil,
+		"next":  nil,
+	}
+	if page > 1 {
+		links["prev"] = link(page - 1)
+	}
+	if page < totalPages {
+		links["next"] = link(page + 1)
+	}
+	return links
 }
 
-var (
-	datasetCache = make(map[int]cacheItem)
-	cacheMutex   sync.RWMutex
-)
-
-// fetchDatasets retrieves datasets for a given page from the external API,
-// caching the result for 5 minutes.
-func fetchDatasets(page int) ([]transformers.Dataset, error) {
-	cacheMutex.RLock()
-	if item, found := datasetCache[page]; found {
-		if time.Now().Before(item.expiration) {
-			cacheMutex.RUnlock()
-			return item.data, nil
+// upstreamFilterParams are the query parameters passed through to the MetaData
+// API, so clients can filter and sort the catalog upstream.
+var upstreamFilterParams = []string{"rawfilter", "rawsort", "searchfilter"}
+
+// maxFilterLength bounds the length of a passed-through filter parameter.
+const maxFilterLength = 500
+
+// upstreamFilters returns the upstreamFilterParams of r, or nil if it has none.
+func upstreamFilters(r *http.Request) url.Values {
+	query := r.URL.Query()
+	var filters url.Values
+	for _, name := range upstreamFilterParams {
+		if v := query.Get(name); v != "" {
+			if filters == nil {
+				filters = url.Values{}
+			}
+			filters.Set(name, v)
 		}
 	}
-	cacheMutex.RUnlock()
-
-	url := fmt.Sprintf("https://tourism.api.opendatahub.com/v1/MetaData?pagenumber=%d&limit=%d", page, pageSize)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		TotalResults int       `json:"TotalResults"`
-		TotalPages   int       `json:"TotalPages"`
-		CurrentPage  int       `json:"CurrentPage"`
-		NextPage     string    `json:"NextPage"`
-		Items        []transformers.Dataset `json:"Items"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Printf("Error decoding JSON on page %d: %v", page, err)
-		return nil, err
-	}
-	if len(data.Items) == 0 {
-		log.Printf("No datasets found on page %d", page)
-		return nil, nil
-	}
-	cacheMutex.Lock()
-	datasetCache[page] = cacheItem{
-		data:       data.Items,
-		expiration: time.Now().Add(5 * time.Minute),
-	}
-	cacheMutex.Unlock()
-	return data.Items, nil
+	return filters
 }
 
-// fetchDatasetsResponse retrieves the complete API response for a given page.
-func fetchDatasetsResponse(page int) (*struct {
-	TotalResults int       `json:"TotalResults"`
-	TotalPages   int       `json:"TotalPages"`
-	CurrentPage  int       `json:"CurrentPage"`
-	NextPage     string    `json:"NextPage"`
-	Items        []transformers.Dataset `json:"Items"`
-}, error) {
-	url := fmt.Sprintf("https://tourism.api.opendatahub.com/v1/MetaData?pagenumber=%d&limit=%d", page, pageSize)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var data struct {
-		TotalResults int       `json:"TotalResults"`
-		TotalPages   int       `json:"TotalPages"`
-		CurrentPage  int       `json:"CurrentPage"`
-		NextPage     string    `json:"NextPage"`
-		Items        []transformers.Dataset `json:"Items"`
+// getPageSize extracts the "pageSize" query parameter from the request
+// (default=10), bounded to catalog.MaxPageSize.
+func getPageSize(r *http.Request) int {
+	size, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil || size < 1 {
+		return defaultPageSize
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Printf("Error decoding JSON on page %d: %v", page, err)
-		return nil, err
+	if size > catalog.MaxPageSize {
+		return catalog.MaxPageSize
 	}
-	if len(data.Items) == 0 {
-		return nil, nil
-	}
-	return &data, nil
+	return size
 }
 
-// getDefaultDatasets returns a default dataset (not used if real data is available).
-func getDefaultDatasets() []transformers.Dataset {
-	return []transformers.Dataset{
-		{
-			ID:        "default",
-			Self:      "https://example.org/default",
-			Type:      "unknown",
-			Shortname: "Default Dataset",
-			ApiUrl:    "https://example.org/api/default",
-		},
+// supportedLanguages are the description languages offered by the upstream catalog.
+var supportedLanguages = map[string]bool{"en": true, "it": true, "de": true}
+
+// getLanguage selects the output language from the "lang" query parameter,
+// then the supported language with the highest q-value in the Accept-Language
+// header (the first one listed among equals), defaulting to
+// transformers.DefaultLanguage.
+func getLanguage(r *http.Request) string {
+	if lang := strings.ToLower(r.URL.Query().Get("lang")); supportedLanguages[lang] {
+		return lang
+	}
+	best, bestQ := transformers.DefaultLanguage, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary == "*" {
+			primary = transformers.DefaultLanguage
+		}
+		if q := qValue(params); supportedLanguages[primary] && q > bestQ {
+			best, bestQ = primary, q
+		}
 	}
+	return best
 }
 
-// getPageNumber extracts the "page" query parameter from the request (default=1).
-func getPageNumber(r *http.Request) int {
-	pageStr := r.URL.Query().Get("page")
-	if pageStr == "" {
-		return 1
-	}
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		return 1
+// qValue returns the q-value in the parameters of an Accept-Language entry,
+// 1 if there is none and 0 if it is invalid.
+func qValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
 	}
-	return page
-}
-
-// slugify converts a string into a slug.
-func slugify(s string) string {
-	s = strings.ToLower(s)
-	s = strings.TrimSpace(s)
-	s = strings.ReplaceAll(s, " ", "-")
-	re := regexp.MustCompile(`[^a-z0-9\-]`)
-	return re.ReplaceAllString(s, "")
+	return 1
 }
 
-// searchDatasetByID fetches the dataset details directly from the external API using the given ID.
-func searchDatasetByID(id string) *transformers.Dataset {
-	log.Printf("Directly fetching dataset detail for ID: %s", id)
-	url := fmt.Sprintf("https://tourism.api.opendatahub.com/v1/MetaData/%s", id)
-	resp, err := http.Get(url)
+// lastChanged returns the LastChange timestamp of ds, or the zero time if it is
+// not an RFC 3339 timestamp.
+func lastChanged(ds transformers.Dataset) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, ds.LastChange)
 	if err != nil {
-		log.Printf("Error fetching detail for ID %s: %v", id, err)
-		return nil
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		log.Printf("Dataset with ID %s not found (404)", id)
-		return nil
-	}
-	var ds transformers.Dataset
-	if err := json.NewDecoder(resp.Body).Decode(&ds); err != nil {
-		log.Printf("Error decoding dataset detail for ID %s: %v", id, err)
-		return nil
+		return time.Time{}
 	}
-	log.Printf("Dataset found: ID: %s, Shortname: %s", ds.ID, ds.Shortname)
-	return &ds
+	return t
 }
@@ -0,0 +1,92 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// profile is a metadata representation a single dataset can be served in.
+type profile struct {
+	transform     func(cat *catalog.Client, p transformers.Publisher, ds transformers.Dataset, lang string) interface{}
+	defaultFormat string
+}
+
+// datasetProfiles holds every representation selectable via ?profile= on /datasets/:uuid.
+var datasetProfiles = map[string]profile{
+	"dcat": {
+		transform: func(cat *catalog.Client, p transformers.Publisher, ds transformers.Dataset, lang string) interface{} {
+			return cat.DCATCatalog(p, []transformers.Dataset{ds}, lang)
+		},
+		defaultFormat: "json",
+	},
+	"odps": {
+		transform: func(cat *catalog.Client, p transformers.Publisher, ds transformers.Dataset, lang string) interface{} {
+			return transformers.ToODPS(p, []transformers.Dataset{ds}, lang)
+		},
+		defaultFormat: "json",
+	},
+	"odps30": {
+		transform: func(cat *catalog.Client, p transformers.Publisher, ds transformers.Dataset, lang string) interface{} {
+			return cat.ODPS30Document(p, ds, lang)
+		},
+		defaultFormat: "yaml",
+	},
+	"odps31": {
+		transform: func(cat *catalog.Client, p transformers.Publisher, ds transformers.Dataset, lang string) interface{} {
+			return cat.ODPS31Document(p, ds, lang)
+		},
+		defaultFormat: "yaml",
+	},
+}
+
+const defaultProfile = "odps31"
+
+// DatasetGinHandler serves GET /datasets/:uuid?profile=dcat|odps|odps30|odps31,
+// the canonical URL of a dataset in any supported representation (default odps31).
+// The default output format is that of the profile's own endpoint. With
+// ?format=html (or /datasets/{uuid}.html) it serves the browsable page of the
+// dataset instead, the page listed in the sitemap.
+func (s *Server) DatasetGinHandler(c *gin.Context) {
+	name := c.DefaultQuery("profile", defaultProfile)
+	p, ok := datasetProfiles[name]
+	if !ok {
+		problem(c, http.StatusBadRequest, "Unknown profile")
+		return
+	}
+
+	datasetID := c.Param("uuid")
+	log.Printf("Dataset endpoint requested for dataset ID: %s (profile %s)", datasetID, name)
+	found := s.catalog.Dataset(c.Request.Context(), datasetID)
+	if found == nil {
+		problem(c, http.StatusNotFound, "Dataset not found")
+		return
+	}
+	ds := *found
+	if c.Query("format") == pageFormat {
+		s.datasetPage(c, ds)
+		return
+	}
+	s.render(c, p.transform(s.catalog, s.publisher(c), ds, getLanguage(c.Request)), p.defaultFormat, ds)
+}
+
+// pageFormat is the ?format= of the browsable dataset page. It is not a
+// renderer, as the page is not a serialization of the profile's document.
+const pageFormat = "html"
+
+// datasetPage renders the site_dataset.html template for ds, the page the
+// static site writes to datasets/{uuid}.html. Its relative links resolve to
+// the same documents below the API.
+func (s *Server) datasetPage(c *gin.Context, ds transformers.Dataset) {
+	c.HTML(http.StatusOK, "site_dataset.html", gin.H{
+		"publisher":   s.publisher(c),
+		"dataset":     ds,
+		"description": ds.ApiDescription[getLanguage(c.Request)],
+	})
+}
@@ -0,0 +1,99 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// DcatDumpGinHandler serves /dcat/dump, a single DCAT catalog containing every
+// dataset of the upstream catalog. Datasets are streamed to the client page by
+// page as they are fetched, so the full document is never held in memory.
+func (s *Server) DcatDumpGinHandler(c *gin.Context) {
+	s.streamDCATCatalog(c, "application/json; charset=utf-8")
+}
+
+// CatalogJSONLDGinHandler serves the complete catalog at the conventional
+// /catalog.jsonld and /.well-known/dcat paths harvesters probe for, with the
+// JSON-LD media type.
+func (s *Server) CatalogJSONLDGinHandler(c *gin.Context) {
+	s.streamDCATCatalog(c, "application/ld+json")
+}
+
+// openDatasetList reopens the marshaled catalog object header to append the
+// dataset list, and opens the list. The members are separated by a comma only
+// if the header has any, as post-processing may have removed them all.
+func openDatasetList(header []byte) []byte {
+	members := bytes.TrimSpace(bytes.TrimSuffix(bytes.TrimSpace(header), []byte("}")))
+	open := append([]byte(nil), members...)
+	if len(open) > 1 {
+		open = append(open, ',')
+	}
+	return append(open, `"dataset":[`...)
+}
+
+// streamDCATCatalog streams the complete DCAT catalog as contentType.
+func (s *Server) streamDCATCatalog(c *gin.Context, contentType string) {
+	ctx := c.Request.Context()
+	p := s.publisher(c)
+	root, err := s.postProcess(ctx, transformers.DCATCatalog(p))
+	if err != nil {
+		problem(c, http.StatusInternalServerError, "Error processing JSON")
+		return
+	}
+	header, err := json.Marshal(root)
+	if err != nil {
+		problem(c, http.StatusInternalServerError, "Error marshaling JSON")
+		return
+	}
+
+	deprecated := c.Query("deprecated")
+	lang := getLanguage(c.Request)
+	enc := json.NewEncoder(c.Writer)
+	started, count := false, 0
+	err = s.catalog.ForEachPage(ctx, func(items []transformers.Dataset) error {
+		if !started {
+			started = true
+			c.Header("Content-Type", contentType)
+			c.Status(http.StatusOK)
+			c.Writer.Write(openDatasetList(header))
+		}
+		docs := s.catalog.DCATDatasets(p, catalog.FilterDeprecated(deprecated, items), lang)
+		for _, doc := range docs {
+			if count > 0 {
+				c.Writer.WriteString(",")
+			}
+			processed, err := s.postProcess(ctx, doc)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(processed); err != nil {
+				return err
+			}
+			count++
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error generating DCAT dump: %v", err)
+		if !started {
+			upstreamUnavailable(c)
+		}
+		// Otherwise the truncated document signals the failure to the client.
+		return
+	}
+	if !started {
+		problem(c, http.StatusNotFound, "No data found")
+		return
+	}
+	c.Writer.WriteString("]}")
+}
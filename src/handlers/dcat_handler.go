@@ -5,42 +5,44 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/catalog"
 	"opendatahub.com/dataset-catalog-api/transformers"
 )
 
-func DcatGinHandler(c *gin.Context) {
-  pageStr := c.Query("page")
-  page := 1
-  if pageStr != "" {
-    if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-      page = p
-    } else {
-      c.String(http.StatusNotFound, "No data found")
-      return
-    }
-  }
-
-  // Fetch paginated datasets.
-  resp, err := fetchDatasetsResponse(page)
-  if err != nil || resp == nil || len(resp.Items) == 0 {
-    c.String(http.StatusNotFound, "No data found")
-    return
-  }
+// DcatGinHandler serves a paginated DCAT catalog.
+// Default output is JSON; use ?format=yaml, toml, ttl or md for other formats.
+func (s *Server) DcatGinHandler(c *gin.Context) {
+	p := s.fetchListingPage(c)
+	if p == nil {
+		return
+	}
+	output := s.catalog.DCATCatalog(s.publisher(c), p.datasets, getLanguage(c.Request))
+	output.Links = s.paginationLinks(c.Request, "dcat", p.page, p.totalPages)
+	s.render(c, output, "json", p.datasets...)
+}
 
-	output := transformers.ToDCAT(ConvertDatasets(resp.Items))
-	format := c.Query("format")
-	if format == "yaml" {
-		yamlData, err := yaml.Marshal(output)
-    if err != nil {
-      c.String(http.StatusInternalServerError, "Error marshaling YAML")
-      return
-    }
-    c.Data(http.StatusOK, "text/plain; charset=utf-8", yamlData)
-	} else {
-	  c.JSON(http.StatusOK, output)
+// DcatDataspaceGinHandler serves GET /dcat/dataspace/:name, a complete DCAT
+// catalog of the datasets of one dataspace, so every community has its own
+// harvestable catalog URL.
+func (s *Server) DcatDataspaceGinHandler(c *gin.Context) {
+	name := strings.ToLower(c.Param("name"))
+	all, err := s.catalog.AllDatasets(c.Request.Context())
+	if err != nil {
+		upstreamUnavailable(c)
+		return
+	}
+	var datasets []transformers.Dataset
+	for _, ds := range catalog.FilterDeprecated(c.Query("deprecated"), all) {
+		if strings.EqualFold(ds.Dataspace, name) {
+			datasets = append(datasets, ds)
+		}
+	}
+	if len(datasets) == 0 {
+		problem(c, http.StatusNotFound, "No datasets found in dataspace "+name)
+		return
 	}
+	s.render(c, transformers.ToDCATDataspace(s.publisher(c), name, datasets, getLanguage(c.Request)), "json", datasets...)
 }
@@ -0,0 +1,81 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns a strong entity tag derived from a response body, so identical
+// content always yields the same tag.
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches tag.
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeData writes a successful response with an ETag, answering 304 Not
+// Modified instead when the client's If-None-Match already has this content.
+func (s *Server) writeData(c *gin.Context, contentType string, body []byte) {
+	s.staleHeaders(c)
+	tag := etag(body)
+	c.Header("ETag", tag)
+	if etagMatches(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// writeEncoded writes a successful response with the body written by encode,
+// streamed to the client as it is encoded. A streamed body has no ETag, since
+// the headers are sent before it is complete; the response cache adds one to
+// the responses it keeps. Encoding errors are returned if nothing was written
+// yet, so the caller can answer with an error instead; later ones can only
+// cut the body short, and are recorded on c so it is not cached.
+func (s *Server) writeEncoded(c *gin.Context, contentType string, encode func(w io.Writer) error) error {
+	s.staleHeaders(c)
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	if err := encode(c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			return err
+		}
+		log.Printf("Error encoding response: %v", err)
+		_ = c.Error(err)
+	}
+	return nil
+}
+
+// staleHeaders flags responses served while the upstream API is failing, as
+// they may be built from last-known-good data: a Warning header and the time
+// of the last successful upstream fetch.
+func (s *Server) staleHeaders(c *gin.Context) {
+	if !s.catalog.UpstreamFailing() {
+		return
+	}
+	c.Header("Warning", `110 - "Response is Stale"`)
+	if last := s.catalog.LastSyncTime(); !last.IsZero() {
+		c.Header("X-Last-Sync", last.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,78 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tag := etag([]byte("body"))
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"missing", "", false},
+		{"same tag", tag, true},
+		{"weak tag", "W/" + tag, true},
+		{"in a list", `"other", ` + tag, true},
+		{"any", "*", true},
+		{"other tag", `"other"`, false},
+		{"tag of other content", etag([]byte("other body")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, tag); got != tt.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	router := newTestRouter(t, 5)
+	tests := []struct {
+		target string
+	}{
+		{"/dcat"},
+		{"/odps31?format=yaml"},
+		{"/odps31/ds-01"},
+		{"/jsonapi/datasets"},
+		{"/datasets/ds-01?profile=dcat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			first := serve(router, tt.target, nil)
+			tag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || tag == "" {
+				t.Fatalf("got status %d and ETag %q", first.Code, tag)
+			}
+			if tag != etag(first.Body.Bytes()) {
+				t.Errorf("ETag %s does not match the body", tag)
+			}
+			cases := []struct {
+				ifNoneMatch string
+				wantStatus  int
+			}{
+				{tag, http.StatusNotModified},
+				{"W/" + tag, http.StatusNotModified},
+				{`"stale"`, http.StatusOK},
+			}
+			for _, c := range cases {
+				w := serve(router, tt.target, http.Header{"If-None-Match": {c.ifNoneMatch}})
+				if w.Code != c.wantStatus {
+					t.Errorf("If-None-Match %s: got status %d, want %d", c.ifNoneMatch, w.Code, c.wantStatus)
+				}
+				if w.Code == http.StatusNotModified && w.Body.Len() > 0 {
+					t.Errorf("If-None-Match %s: 304 with a body of %d bytes", c.ifNoneMatch, w.Body.Len())
+				}
+				if got := w.Header().Get("ETag"); got != tag {
+					t.Errorf("If-None-Match %s: got ETag %s, want %s", c.ifNoneMatch, got, tag)
+				}
+			}
+		})
+	}
+}
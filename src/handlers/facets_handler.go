@@ -0,0 +1,71 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// facetValue is one distinct value of a facet and the number of datasets having it.
+type facetValue struct {
+	Value string `json:"value" yaml:"value" toml:"value"`
+	Count int    `json:"count" yaml:"count" toml:"count"`
+}
+
+// FacetsGinHandler serves GET /facets, the distinct values and dataset counts of
+// type, category, dataspace, data provider and license across the whole catalog.
+func (s *Server) FacetsGinHandler(c *gin.Context) {
+	all, err := s.catalog.AllDatasets(c.Request.Context())
+	if err != nil {
+		upstreamUnavailable(c)
+		return
+	}
+
+	counts := map[string]map[string]int{
+		"type":         {},
+		"category":     {},
+		"dataspace":    {},
+		"dataProvider": {},
+		"license":      {},
+	}
+	for _, ds := range catalog.FilterDeprecated(c.Query("deprecated"), all) {
+		counts["type"][ds.Type]++
+		counts["dataspace"][ds.Dataspace]++
+		counts["license"][ds.LicenseInfo.License]++
+		for _, category := range ds.Category {
+			counts["category"][category]++
+		}
+		for _, provider := range ds.DataProvider {
+			counts["dataProvider"][provider]++
+		}
+	}
+
+	output := make(map[string]interface{}, len(counts))
+	for facet, values := range counts {
+		output[facet] = sortedFacetValues(values)
+	}
+	s.render(c, output, "json")
+}
+
+// sortedFacetValues orders facet values by descending count, then by value.
+// Empty values are skipped.
+func sortedFacetValues(values map[string]int) []facetValue {
+	out := []facetValue{}
+	for value, count := range values {
+		if value == "" {
+			continue
+		}
+		out = append(out, facetValue{Value: value, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
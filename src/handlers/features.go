@@ -0,0 +1,72 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"log"
+	"slices"
+	"strings"
+)
+
+// applyFeatureFlags removes the routes and output formats switched off in the
+// environment from Routes and renderers, so they are neither served nor
+// listed on the index page, in the OpenAPI description or in /version:
+//   - DISABLED_ROUTES, route paths to switch off, e.g. /odps,/odps30/:uuid;
+//   - ENABLED_ROUTES, Experimental route paths to switch on;
+//   - DISABLED_FORMATS, ?format= values to switch off, e.g. toml,md.
+//
+// A path ending in * matches every path it prefixes, e.g. /odps30*. JSON
+// cannot be switched off, as it is the fallback of every endpoint.
+func (s *Server) applyFeatureFlags() {
+	disabled := s.cfg.Server.DisabledRoutes
+	enabled := s.cfg.Server.EnabledRoutes
+	var routes []Route
+	for _, r := range s.Routes {
+		if !routeEnabled(r.Path, r.Experimental, enabled, disabled) {
+			log.Printf("Route %s is disabled", r.Path)
+			continue
+		}
+		routes = append(routes, r)
+	}
+	s.Routes = routes
+
+	var formats []string
+	for _, format := range s.cfg.Server.DisabledFormats {
+		if format == "json" {
+			log.Printf("Format json cannot be disabled")
+			continue
+		}
+		delete(s.renderers, format)
+		formats = append(formats, format)
+		log.Printf("Format %s is disabled", format)
+	}
+	for i, r := range s.Routes {
+		s.Routes[i].Formats = slices.DeleteFunc(slices.Clone(r.Formats), func(f string) bool {
+			return slices.Contains(formats, f)
+		})
+	}
+}
+
+// routeEnabled reports whether the route at path is served: experimental
+// routes only if listed in enabled, others unless listed in disabled.
+func routeEnabled(path string, experimental bool, enabled, disabled []string) bool {
+	if experimental && !matchesRoute(enabled, path) {
+		return false
+	}
+	return !matchesRoute(disabled, path)
+}
+
+// matchesRoute reports whether path is one of patterns, where a pattern
+// ending in * matches every path it prefixes.
+func matchesRoute(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if p == path {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formatSuffixAliases maps file extensions that differ from the format name.
+var formatSuffixAliases = map[string]string{
+	"yml":    "yaml",
+	"jsonld": "json",
+}
+
+// splitFormatSuffix splits a file extension naming an output format off the
+// last path segment: "/odps31/abc.yaml" yields "/odps31/abc" and "yaml". Paths
+// without a known extension are returned unchanged with an empty format.
+func (s *Server) splitFormatSuffix(p string) (string, string) {
+	ext := path.Ext(p)
+	if ext == "" {
+		return p, ""
+	}
+	format := strings.TrimPrefix(ext, ".")
+	if alias, ok := formatSuffixAliases[format]; ok {
+		format = alias
+	}
+	if _, ok := s.renderers[format]; !ok && format != pageFormat {
+		return p, ""
+	}
+	return strings.TrimSuffix(p, ext), format
+}
+
+// FormatSuffixHandler wraps router so that a format extension on the request
+// path, as in /dcat.ttl or /odps31/{uuid}.yaml, selects the output format like
+// ?format= does. Registered paths that end in an extension themselves (such as
+// /sitemap.xml or /openapi.json) are left alone, and /openapi.yaml is served by
+// /openapi.json. It must be created after all routes are registered.
+func (s *Server) FormatSuffixHandler(router *gin.Engine) http.Handler {
+	static := map[string]bool{}
+	for _, route := range router.Routes() {
+		if !strings.ContainsAny(route.Path, ":*") {
+			static[route.Path] = true
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !static[r.URL.Path] {
+			if p, format := s.splitFormatSuffix(r.URL.Path); format != "" {
+				if static[p+".json"] {
+					p += ".json"
+				}
+				r.URL.Path = p
+				r.URL.RawPath = ""
+				q := r.URL.Query()
+				q.Set("format", format)
+				r.URL.RawQuery = q.Encode()
+			}
+		}
+		router.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// LoadTrustedProxies loads TRUSTED_PROXIES, a comma-separated list of the IP
+// addresses and CIDR ranges of the reverse proxies in front of the service.
+// Requests from them are served with links built from the scheme and host
+// they were sent to, as forwarded by the proxy. Without TRUSTED_PROXIES the
+// forwarded headers are ignored.
+func (s *Server) LoadTrustedProxies() error {
+	var loaded []netip.Prefix
+	for _, entry := range s.cfg.Server.TrustedProxies {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(entry)
+			if aerr != nil {
+				return fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		loaded = append(loaded, prefix.Masked())
+	}
+	s.trustedProxies = loaded
+	if len(loaded) > 0 {
+		log.Printf("Trusting forwarded headers from %d proxy ranges", len(loaded))
+	}
+	return nil
+}
+
+// trustedProxy reports whether the peer of r is one of trustedProxies.
+func (s *Server) trustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedOrigin returns the scheme and host a request from a trusted proxy
+// was sent to, from X-Forwarded-Proto and X-Forwarded-Host. Either is empty
+// if not forwarded or invalid; ok is false if neither is usable.
+func (s *Server) forwardedOrigin(r *http.Request) (scheme, host string, ok bool) {
+	if len(s.trustedProxies) == 0 || !s.trustedProxy(r) {
+		return "", "", false
+	}
+	// Proxies chained behind each other append to the headers, the first
+	// value is the one the client sent.
+	scheme = strings.ToLower(firstForwarded(r.Header.Get("X-Forwarded-Proto")))
+	if scheme != "http" && scheme != "https" {
+		scheme = ""
+	}
+	host = firstForwarded(r.Header.Get("X-Forwarded-Host"))
+	if strings.ContainsAny(host, "/\\?#@ \t") {
+		host = ""
+	}
+	return scheme, host, scheme != "" || host != ""
+}
+
+// firstForwarded returns the first value of a comma-separated forwarded header.
+func firstForwarded(v string) string {
+	v, _, _ = strings.Cut(v, ",")
+	return strings.TrimSpace(v)
+}
+
+// rebaseURL returns baseURL with its scheme and host replaced by the non-empty
+// ones of scheme and host, keeping its path.
+func rebaseURL(baseURL, scheme, host string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	if scheme != "" {
+		u.Scheme = scheme
+	}
+	if host != "" {
+		u.Host = host
+	}
+	return u.String()
+}
@@ -0,0 +1,143 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/catalogpb"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// CatalogServer implements catalogpb.CatalogServiceServer on top of the same
+// fetch and cache layer used by the HTTP handlers.
+type CatalogServer struct {
+	catalogpb.UnimplementedCatalogServiceServer
+	// Catalog is the client the datasets are read from.
+	Catalog *catalog.Client
+}
+
+// ListDatasets returns one page of the catalog. Deprecated datasets are left
+// out unless the request asks for them, as in the HTTP listings.
+func (s CatalogServer) ListDatasets(ctx context.Context, req *catalogpb.ListDatasetsRequest) (*catalogpb.Catalog, error) {
+	page := int(req.GetPage())
+	if page == 0 {
+		page = 1
+	}
+	if page < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page must be positive")
+	}
+	switch req.GetDeprecated() {
+	case "", "include", "exclude", "only":
+	default:
+		return nil, status.Error(codes.InvalidArgument, "deprecated must be one of include, exclude, only")
+	}
+	resp, err := s.Catalog.Page(ctx, page, defaultPageSize, nil, req.GetDeprecated())
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "error fetching data")
+	}
+	if resp == nil {
+		return nil, status.Error(codes.NotFound, "no data found")
+	}
+	out := &catalogpb.Catalog{
+		CurrentPage:  int32(resp.CurrentPage),
+		TotalPages:   int32(math.Ceil(float64(resp.TotalResults) / float64(defaultPageSize))),
+		TotalRecords: int32(resp.TotalResults),
+	}
+	for _, ds := range resp.Items {
+		out.Datasets = append(out.Datasets, toProtoDataset(ds))
+	}
+	return out, nil
+}
+
+// GetDataset returns a single dataset by ID.
+func (s CatalogServer) GetDataset(ctx context.Context, req *catalogpb.GetDatasetRequest) (*catalogpb.Dataset, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing dataset ID")
+	}
+	found := s.Catalog.Dataset(ctx, req.GetId())
+	if found == nil {
+		return nil, status.Error(codes.NotFound, "dataset not found")
+	}
+	return toProtoDataset(*found), nil
+}
+
+// StreamChanges polls the aggregated catalog and sends every dataset whose
+// LastChange is newer than the latest one already sent.
+func (s CatalogServer) StreamChanges(req *catalogpb.StreamChangesRequest, stream catalogpb.CatalogService_StreamChangesServer) error {
+	interval := time.Duration(req.GetPollIntervalSeconds()) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	var since time.Time
+	if req.GetSince() != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339Nano, req.GetSince()); err != nil {
+			return status.Error(codes.InvalidArgument, "since must be an RFC 3339 timestamp")
+		}
+	}
+	// Without since, the first poll sends every dataset, including those
+	// without a valid LastChange.
+	all := since.IsZero()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		datasets, err := s.Catalog.AllDatasets(stream.Context())
+		if err != nil {
+			return status.Error(codes.Unavailable, "error fetching data")
+		}
+		latest := since
+		for _, ds := range datasets {
+			changed := lastChanged(ds)
+			if !all && !changed.After(since) {
+				continue
+			}
+			if err := stream.Send(&catalogpb.DatasetChange{Dataset: toProtoDataset(ds)}); err != nil {
+				return err
+			}
+			if changed.After(latest) {
+				latest = changed
+			}
+		}
+		since, all = latest, false
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func toProtoDataset(ds transformers.Dataset) *catalogpb.Dataset {
+	return &catalogpb.Dataset{
+		Id:           ds.ID,
+		Self:         ds.Self,
+		Type:         ds.Type,
+		Shortname:    ds.Shortname,
+		ApiUrl:       ds.ApiUrl,
+		ApiType:      ds.ApiType,
+		BaseUrl:      ds.BaseUrl,
+		SwaggerUrl:   ds.SwaggerUrl,
+		Dataspace:    ds.Dataspace,
+		Category:     ds.Category,
+		DataProvider: ds.DataProvider,
+		Deprecated:   ds.Deprecated,
+		FirstImport:  ds.FirstImport,
+		LastChange:   ds.LastChange,
+		License: &catalogpb.License{
+			Author:        ds.LicenseInfo.Author,
+			License:       ds.LicenseInfo.License,
+			ClosedData:    ds.LicenseInfo.ClosedData,
+			LicenseHolder: ds.LicenseInfo.LicenseHolder,
+		},
+		ApiDescription: ds.ApiDescription,
+	}
+}
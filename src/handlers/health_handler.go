@@ -0,0 +1,120 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// healthTimeout bounds each dependency probe of the deep health check.
+const healthTimeout = 5 * time.Second
+
+// dependencyStatus is the result of probing one dependency.
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	Backend   string `json:"backend,omitempty"`
+	LatencyMs int64  `json:"latencyMs,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Failover is the upstream failover state, if UPSTREAM_FALLBACK_URL is set.
+	Failover *catalog.FailoverStatus `json:"failover,omitempty"`
+}
+
+// HealthcheckGinHandler serves /healthcheck, the liveness probe. With
+// ?deep=true it probes the dependencies like ReadyGinHandler.
+func (s *Server) HealthcheckGinHandler(c *gin.Context) {
+	if c.Query("deep") == "true" {
+		s.ReadyGinHandler(c)
+		return
+	}
+	body := gin.H{
+		"status":   "ok",
+		"lastSync": formatSyncTime(s.catalog.LastSyncTime()),
+	}
+	if failover := s.catalog.UpstreamFailover(); failover != nil {
+		body["failover"] = failover
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// ReadyGinHandler serves /ready, the readiness probe. It probes the upstream
+// MetaData API, the page cache backend, the persistent cache and the
+// invalidation channel and reports each one's status. An unreachable upstream
+// API only degrades the service, as it keeps serving last-known-good data;
+// any other failing dependency makes it answer 503. The upstream check
+// includes the failover state, and goes to the fallback while failed over. In
+// offline mode the upstream API is reported as "offline". Once the service is
+// shutting down, it answers 503 without probing anything.
+func (s *Server) ReadyGinHandler(c *gin.Context) {
+	if s.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+	checks := map[string]dependencyStatus{
+		"upstream":        probe(c.Request.Context(), s.catalog.ProbeUpstream),
+		"cache":           probe(c.Request.Context(), s.catalog.ProbeCache),
+		"persistentCache": {Status: "disabled"},
+		"invalidation":    {Status: "disabled"},
+	}
+	if s.catalog.OfflineMode() {
+		checks["upstream"] = dependencyStatus{Status: "offline"}
+	}
+	upstream := checks["upstream"]
+	upstream.Failover = s.catalog.UpstreamFailover()
+	checks["upstream"] = upstream
+	cache := checks["cache"]
+	cache.Backend = s.catalog.CacheBackend()
+	checks["cache"] = cache
+	if s.catalog.PersistentCacheEnabled() {
+		checks["persistentCache"] = probe(c.Request.Context(), s.catalog.ProbePersistentCache)
+	}
+	if s.catalog.InvalidationEnabled() {
+		checks["invalidation"] = probe(c.Request.Context(), s.catalog.ProbeInvalidation)
+	}
+
+	status, code := "ok", http.StatusOK
+	for name, check := range checks {
+		if check.Status != "down" {
+			continue
+		}
+		if name == "upstream" {
+			if status == "ok" {
+				status = "degraded"
+			}
+			continue
+		}
+		status, code = "down", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":   status,
+		"lastSync": formatSyncTime(s.catalog.LastSyncTime()),
+		"checks":   checks,
+	})
+}
+
+// probe runs check with healthTimeout and reports its outcome.
+func probe(ctx context.Context, check func(ctx context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		return dependencyStatus{Status: "down", LatencyMs: latency, Error: err.Error()}
+	}
+	return dependencyStatus{Status: "up", LatencyMs: latency}
+}
+
+// formatSyncTime formats the time of the last successful upstream fetch, or
+// returns nil if there was none.
+func formatSyncTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -5,20 +5,59 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// IndexHandler renders an index HTML page listing all available endpoints.
-func IndexHandler(c *gin.Context) {
-	// Define a list of endpoint paths.
-	endpoints := []string{
-		"/dcat",
-		"/odps",
-		"/odps30",
-		"/odps31",
+// endpointCard is one endpoint shown on the index page.
+type endpointCard struct {
+	Path        string
+	Description string
+	Count       int
+	ShowCount   bool
+	FormatLinks []formatLink
+}
+
+type formatLink struct {
+	Format string
+	URL    string
+}
+
+// IndexHandler renders an index HTML page with a card for every endpoint in the
+// route registry, including the catalog size, the last upstream sync time and
+// links to each output format.
+func (s *Server) IndexHandler(c *gin.Context) {
+	total := -1
+	if resp, err := s.catalog.Page(c.Request.Context(), 1, defaultPageSize, nil, ""); err == nil && resp != nil {
+		total = resp.TotalResults
+	}
+
+	// Under a path prefix profile, link to the endpoints below the prefix.
+	prefix := s.publisherProfileFrom(c.Request.Context()).PathPrefix
+	var cards []endpointCard
+	for _, r := range s.Routes {
+		if strings.Contains(r.Path, ":") {
+			continue
+		}
+		card := endpointCard{
+			Path:        prefix + r.Path,
+			Description: r.Description,
+			Count:       total,
+			ShowCount:   r.ShowCount && total >= 0,
+		}
+		for _, f := range r.Formats {
+			card.FormatLinks = append(card.FormatLinks, formatLink{Format: strings.ToUpper(f), URL: prefix + r.Path + "?format=" + f})
+		}
+		cards = append(cards, card)
+	}
+
+	lastSyncText := "never"
+	if t := s.catalog.LastSyncTime(); !t.IsZero() {
+		lastSyncText = t.UTC().Format("2006-01-02 15:04:05 UTC")
 	}
 	c.HTML(http.StatusOK, "index.html", gin.H{
-		"endpoints": endpoints,
+		"endpoints": cards,
+		"lastSync":  lastSyncText,
 	})
 }
@@ -0,0 +1,178 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+const jsonAPIContentType = "application/vnd.api+json"
+
+// JSONAPIDatasetsGinHandler serves the JSON:API "datasets" collection.
+// GET /jsonapi/datasets?page[number]={n}&page[size]={s}&fields[datasets]=a,b returns one page of
+// resource objects together with pagination links; ?filter[id]=a,b returns
+// the datasets with the given IDs or slugs instead.
+func (s *Server) JSONAPIDatasetsGinHandler(c *gin.Context) {
+	if ids := c.Query("filter[id]"); ids != "" {
+		s.jsonAPIDatasetsByID(c, strings.Split(ids, ","))
+		return
+	}
+	page := 1
+	if pageStr := c.Query("page[number]"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			s.jsonAPIError(c, http.StatusBadRequest, "Invalid page number")
+			return
+		}
+		page = p
+	}
+
+	pageSize := defaultPageSize
+	if sizeStr := c.Query("page[size]"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < 1 || size > catalog.MaxPageSize {
+			s.jsonAPIError(c, http.StatusBadRequest, "Invalid page size")
+			return
+		}
+		pageSize = size
+	}
+
+	resp, err := s.catalog.Page(c.Request.Context(), page, pageSize, upstreamFilters(c.Request), c.Query("deprecated"))
+	if err != nil {
+		s.jsonAPIError(c, http.StatusServiceUnavailable, upstreamUnavailableDetail)
+		return
+	}
+	if resp == nil {
+		s.jsonAPIError(c, http.StatusNotFound, "No data found")
+		return
+	}
+
+	fields := sparseFields(c)
+	data := []map[string]interface{}{}
+	for _, ds := range resp.Items {
+		data = append(data, transformers.ToJSONAPIResource(s.publisher(c), ds, fields))
+	}
+
+	totalPages := int(math.Ceil(float64(resp.TotalResults) / float64(pageSize)))
+	links := map[string]interface{}{
+		"self":  s.jsonAPIPageLink(c, page, pageSize),
+		"first": s.jsonAPIPageLink(c, 1, pageSize),
+		"last":  s.jsonAPIPageLink(c, totalPages, pageSize),
+		"prev":  nil,
+		"next":  nil,
+	}
+	if page > 1 {
+		links["prev"] = s.jsonAPIPageLink(c, page-1, pageSize)
+	}
+	if page < totalPages {
+		links["next"] = s.jsonAPIPageLink(c, page+1, pageSize)
+	}
+
+	s.jsonAPIWrite(c, http.StatusOK, map[string]interface{}{
+		"data":  data,
+		"links": links,
+		"meta": map[string]interface{}{
+			"current_page": page,
+			"total_pages":  totalPages,
+			"totalRecord":  resp.TotalResults,
+		},
+	})
+}
+
+// jsonAPIDatasetsByID serves the datasets of the collection with the given
+// IDs or slugs, in the order given, for clients resolving many datasets in one
+// request. Unknown IDs are left out; deprecated datasets are included, as by
+// the resource endpoint. At most catalog.MaxPageSize IDs are accepted.
+func (s *Server) jsonAPIDatasetsByID(c *gin.Context, ids []string) {
+	if len(ids) > catalog.MaxPageSize {
+		s.jsonAPIError(c, http.StatusBadRequest, fmt.Sprintf("At most %d IDs can be requested at once", catalog.MaxPageSize))
+		return
+	}
+	var found []transformers.Dataset
+	for _, id := range ids {
+		if !datasetIDPattern.MatchString(id) {
+			s.jsonAPIError(c, http.StatusBadRequest, fmt.Sprintf("Invalid dataset ID %q", id))
+			return
+		}
+		if ds := s.catalog.Dataset(c.Request.Context(), id); ds != nil {
+			found = append(found, *ds)
+		}
+	}
+	fields := sparseFields(c)
+	data := []map[string]interface{}{}
+	for _, ds := range found {
+		data = append(data, transformers.ToJSONAPIResource(s.publisher(c), ds, fields))
+	}
+	s.jsonAPIWrite(c, http.StatusOK, map[string]interface{}{
+		"data":  data,
+		"links": map[string]interface{}{"self": s.publisher(c).BaseURL + "jsonapi/datasets?filter[id]=" + strings.Join(ids, ",")},
+		"meta":  map[string]interface{}{"totalRecord": len(data)},
+	})
+}
+
+// JSONAPIDatasetGinHandler serves a single JSON:API "datasets" resource.
+func (s *Server) JSONAPIDatasetGinHandler(c *gin.Context) {
+	found := s.catalog.Dataset(c.Request.Context(), c.Param("uuid"))
+	if found == nil {
+		s.jsonAPIError(c, http.StatusNotFound, "Dataset not found")
+		return
+	}
+	s.jsonAPIWrite(c, http.StatusOK, map[string]interface{}{
+		"data": transformers.ToJSONAPIResource(s.publisher(c), *found, sparseFields(c)),
+	})
+}
+
+// sparseFields reads the fields[datasets] query parameter.
+func sparseFields(c *gin.Context) []string {
+	raw := c.Query("fields[datasets]")
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+func (s *Server) jsonAPIPageLink(c *gin.Context, page, pageSize int) string {
+	link := fmt.Sprintf("%sjsonapi/datasets?page[number]=%d&page[size]=%d", s.publisher(c).BaseURL, page, pageSize)
+	if filters := upstreamFilters(c.Request); filters != nil {
+		link += "&" + filters.Encode()
+	}
+	return link
+}
+
+// jsonAPIError writes a JSON:API error document.
+func (s *Server) jsonAPIError(c *gin.Context, status int, title string) {
+	s.jsonAPIWrite(c, status, map[string]interface{}{
+		"errors": []map[string]string{
+			{"id": c.GetString(requestIDKey), "status": strconv.Itoa(status), "title": title},
+		},
+	})
+}
+
+func (s *Server) jsonAPIWrite(c *gin.Context, status int, doc map[string]interface{}) {
+	if status == http.StatusOK {
+		err := s.writeEncoded(c, jsonAPIContentType, func(w io.Writer) error {
+			return encodeJSON(w, doc)
+		})
+		if err != nil {
+			problem(c, http.StatusInternalServerError, "Error marshaling JSON")
+		}
+		return
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		problem(c, http.StatusInternalServerError, "Error marshaling JSON")
+		return
+	}
+	c.Data(status, jsonAPIContentType, data)
+}
@@ -0,0 +1,51 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// LatestDatasetsGinHandler serves GET /datasets/latest?limit={n}, the n most
+// recently modified datasets of the whole catalog (default 10, at most 100).
+func (s *Server) LatestDatasetsGinHandler(c *gin.Context) {
+	limit := defaultPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			problem(c, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(l, catalog.MaxPageSize)
+	}
+
+	all, err := s.catalog.AllDatasets(c.Request.Context())
+	if err != nil {
+		upstreamUnavailable(c)
+		return
+	}
+	datasets := catalog.FilterDeprecated(c.Query("deprecated"), all)
+	sort.SliceStable(datasets, func(i, j int) bool {
+		return lastChanged(datasets[i]).After(lastChanged(datasets[j]))
+	})
+	if len(datasets) > limit {
+		datasets = datasets[:limit]
+	}
+
+	items := []map[string]interface{}{}
+	for _, ds := range datasets {
+		items = append(items, map[string]interface{}{
+			"uuid":        ds.ID,
+			"datasetName": ds.Shortname,
+			"lastChange":  ds.LastChange,
+			"url":         s.publisher(c).BaseURL + "datasets/" + ds.ID,
+		})
+	}
+	s.render(c, map[string]interface{}{"datasets": items}, "json", datasets...)
+}
@@ -0,0 +1,83 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// listingPage is one page of a listing endpoint with its pagination metadata.
+type listingPage struct {
+	page         int
+	totalPages   int
+	totalRecords int
+	// datasets are the page's datasets, kept by ?deprecated=.
+	datasets []transformers.Dataset
+}
+
+// fetchListingPage loads the page selected by ?page= and ?pageSize=. Upstream
+// errors answer 503 and pages beyond the last one 404 "No data found"; in both
+// cases the response is written and nil is returned.
+func (s *Server) fetchListingPage(c *gin.Context) *listingPage {
+	page := getPageNumber(c.Request)
+	pageSize := getPageSize(c.Request)
+	resp, err := s.catalog.Page(c.Request.Context(), page, pageSize, upstreamFilters(c.Request), c.Query("deprecated"))
+	if err != nil {
+		upstreamUnavailable(c)
+		return nil
+	}
+	if resp == nil {
+		problem(c, http.StatusNotFound, "No data found")
+		return nil
+	}
+	totalPages := int(math.Ceil(float64(resp.TotalResults) / float64(pageSize)))
+	if page > totalPages {
+		problem(c, http.StatusNotFound, "No data found")
+		return nil
+	}
+	return &listingPage{
+		page:         page,
+		totalPages:   totalPages,
+		totalRecords: resp.TotalResults,
+		datasets:     resp.Items,
+	}
+}
+
+// listingMetadata returns the pagination fields of p shared by all listing
+// outputs.
+func (s *Server) listingMetadata(c *gin.Context, p *listingPage, path string) map[string]interface{} {
+	return map[string]interface{}{
+		"current_page": p.page,
+		"total_pages":  p.totalPages,
+		"totalRecord":  p.totalRecords,
+		"links":        s.paginationLinks(c.Request, path, p.page, p.totalPages),
+	}
+}
+
+// renderODPSListing serves a paginated list of dataset endpoints whose detail
+// documents live under path (odps30 or odps31).
+func (s *Server) renderODPSListing(c *gin.Context, path string) {
+	p := s.fetchListingPage(c)
+	if p == nil {
+		return
+	}
+
+	var endpoints []map[string]interface{}
+	for _, ds := range p.datasets {
+		endpoints = append(endpoints, map[string]interface{}{
+			"uuid":        ds.ID,
+			"datasetName": ds.Shortname,
+			"originalUrl": ds.ApiUrl,
+			"url":         s.publisher(c).BaseURL + path + "/" + ds.ID,
+		})
+	}
+
+	output := s.listingMetadata(c, p, path)
+	output["endpoints"] = endpoints
+	s.render(c, output, "yaml", p.datasets...)
+}
@@ -0,0 +1,165 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/config"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// newTestRouter returns a router serving every route of a server in offline
+// mode on a catalog of n datasets, ds-01, ds-02, ..., every third of them
+// deprecated, with the request ID and validation middleware of the service.
+func newTestRouter(t *testing.T, n int) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var datasets []transformers.Dataset
+	for i := 1; i <= n; i++ {
+		datasets = append(datasets, transformers.Dataset{
+			ID:         fmt.Sprintf("ds-%02d", i),
+			Shortname:  fmt.Sprintf("Dataset %d", i),
+			ApiUrl:     fmt.Sprintf("https://example.com/v1/Dataset%d", i),
+			Deprecated: i%3 == 0,
+			LastChange: "2024-05-01T08:00:00Z",
+		})
+	}
+	body, err := json.Marshal(datasets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := filepath.Join(t.TempDir(), "datasets.json")
+	if err := os.WriteFile(fixtures, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Offline = true
+	client := catalog.New(cfg)
+	if err := client.LoadFixtures(fixtures); err != nil {
+		t.Fatal(err)
+	}
+	s := New(cfg, client, prometheus.NewRegistry())
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), s.ValidationMiddleware())
+	s.RegisterRoutes(router)
+	return router
+}
+
+// serve answers a GET request for target with router.
+func serve(router http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestListing(t *testing.T) {
+	tests := []struct {
+		target        string
+		wantStatus    int
+		wantEndpoints int
+		wantTotal     int
+		wantPages     int
+		wantNext      bool
+	}{
+		{"/odps31?format=json", http.StatusOK, 10, 17, 2, true},
+		{"/odps31?format=json&page=2", http.StatusOK, 7, 17, 2, false},
+		{"/odps31?format=json&page=3", http.StatusNotFound, 0, 0, 0, false},
+		{"/odps31?format=json&page=0", http.StatusBadRequest, 0, 0, 0, false},
+		{"/odps31?format=json&pageSize=5&page=4", http.StatusOK, 2, 17, 4, false},
+		{"/odps31?format=json&pageSize=100", http.StatusOK, 17, 17, 1, false},
+		{"/odps31?format=json&pageSize=101", http.StatusBadRequest, 0, 0, 0, false},
+		{"/odps31?format=json&deprecated=include", http.StatusOK, 10, 25, 3, true},
+		{"/odps31?format=json&deprecated=include&page=3", http.StatusOK, 5, 25, 3, false},
+		{"/odps31?format=json&deprecated=include&page=4", http.StatusNotFound, 0, 0, 0, false},
+		{"/odps31?format=json&deprecated=only", http.StatusOK, 8, 8, 1, false},
+		{"/odps31?format=json&deprecated=only&page=2", http.StatusNotFound, 0, 0, 0, false},
+		{"/odps30?format=json&deprecated=exclude&page=2", http.StatusOK, 7, 17, 2, false},
+	}
+	router := newTestRouter(t, 25)
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(router, tt.target, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var got struct {
+				Endpoints []map[string]interface{} `json:"endpoints"`
+				Total     int                      `json:"totalRecord"`
+				Pages     int                      `json:"total_pages"`
+				Links     map[string]interface{}   `json:"links"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Endpoints) != tt.wantEndpoints {
+				t.Errorf("got %d endpoints, want %d", len(got.Endpoints), tt.wantEndpoints)
+			}
+			if got.Total != tt.wantTotal || got.Pages != tt.wantPages {
+				t.Errorf("got %d datasets on %d pages, want %d on %d", got.Total, got.Pages, tt.wantTotal, tt.wantPages)
+			}
+			if next := got.Links["next"] != nil; next != tt.wantNext {
+				t.Errorf("got next link %v, want %v", got.Links["next"], tt.wantNext)
+			}
+		})
+	}
+}
+
+// benchServer is the server of the benchmarks, with the catalog in offline
+// mode on the bundled fixtures.
+var benchServer = sync.OnceValues(func() (*Server, error) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.Offline = true
+	client := catalog.New(cfg)
+	if err := client.LoadFixtures(""); err != nil {
+		return nil, err
+	}
+	return New(cfg, client, prometheus.NewRegistry()), nil
+})
+
+// benchListing serves target with handler until b.N requests are done. Pages
+// and documents are cached after the first request, as for a hot listing.
+func benchListing(b *testing.B, handler func(*Server, *gin.Context), target string) {
+	s, err := benchServer()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		handler(s, c)
+		if w.Code != http.StatusOK {
+			b.Fatalf("%s: status %d", target, w.Code)
+		}
+	}
+}
+
+func BenchmarkDcatGinHandler(b *testing.B) {
+	benchListing(b, (*Server).DcatGinHandler, "/dcat")
+}
+
+func BenchmarkODPS31GinHandler(b *testing.B) {
+	benchListing(b, (*Server).ODPS31GinHandler, "/odps31")
+}
@@ -0,0 +1,47 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// NDJSONExportGinHandler serves /export/ndjson, streaming every dataset of the
+// upstream catalog as one JSON object per line, page by page.
+func (s *Server) NDJSONExportGinHandler(c *gin.Context) {
+	deprecated := c.Query("deprecated")
+	started := false
+	err := s.catalog.ForEachPage(c.Request.Context(), func(items []transformers.Dataset) error {
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		// json.Encoder terminates every value with a newline.
+		enc := json.NewEncoder(c.Writer)
+		for _, ds := range catalog.FilterDeprecated(deprecated, items) {
+			if err := enc.Encode(ds); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error generating NDJSON export: %v", err)
+		if !started {
+			upstreamUnavailable(c)
+		}
+		return
+	}
+	if !started {
+		problem(c, http.StatusNotFound, "No data found")
+	}
+}
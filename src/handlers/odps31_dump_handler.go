@@ -0,0 +1,122 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// odpsDumpTTL is how long a completed ODPS export is served before it is regenerated.
+const odpsDumpTTL = 5 * time.Minute
+
+// dumpBatchSize is the number of datasets of the ODPS 3.1 dump transformed
+// concurrently before they are written.
+const dumpBatchSize = 100
+
+// ODPS31DumpGinHandler serves /odps31/dump, the ODPS 3.1 documents of every dataset.
+// Default output is a YAML multi-document stream; use ?format=json for a JSON array
+// or ?format=ndjson for one JSON document per line.
+// ?offset={n} skips the first n documents, letting clients resume an interrupted download.
+// ?deprecated=only|exclude|include selects deprecated datasets (default exclude).
+// While the first export is still being generated the endpoint answers 202 with its progress.
+func (s *Server) ODPS31DumpGinHandler(c *gin.Context) {
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			problem(c, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+		offset = o
+	}
+
+	all, ready := s.odps31Dump.Snapshot()
+	if !ready {
+		done, total := s.odps31Dump.Progress()
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":      "generating",
+			"pages_done":  done,
+			"total_pages": total,
+		})
+		return
+	}
+	datasets := catalog.FilterDeprecated(c.Query("deprecated"), s.publisherProfileFrom(c.Request.Context()).Dataspaces.Filter(all))
+	if offset > len(datasets) {
+		offset = len(datasets)
+	}
+	datasets = datasets[offset:]
+	p := s.publisher(c)
+	// documents calls fn with the index and post-processed document of every
+	// dataset in order, transforming a batch of datasets at a time
+	// concurrently. Every batch is flushed to the client before the next one
+	// is transformed, so the response is sent in chunks and a slow client
+	// holds back the work.
+	documents := func(fn func(i int, doc interface{}) error) error {
+		for start := 0; start < len(datasets); start += dumpBatchSize {
+			batch := datasets[start:min(start+dumpBatchSize, len(datasets))]
+			docs := s.catalog.ODPS31Documents(p, batch, transformers.DefaultLanguage)
+			for j, doc := range docs {
+				processed, err := s.postProcess(c.Request.Context(), doc)
+				if err != nil {
+					return err
+				}
+				if err := fn(start+j, processed); err != nil {
+					return err
+				}
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}
+
+	c.Header("X-Total-Count", strconv.Itoa(offset+len(datasets)))
+	c.Status(http.StatusOK)
+	switch c.Query("format") {
+	case "json":
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(c.Writer)
+		c.Writer.WriteString("[")
+		err := documents(func(i int, doc interface{}) error {
+			if i > 0 {
+				c.Writer.WriteString(",")
+			}
+			return enc.Encode(doc)
+		})
+		if err != nil {
+			log.Printf("Error encoding ODPS31 dump: %v", err)
+			return
+		}
+		c.Writer.WriteString("]")
+	case "ndjson":
+		c.Header("Content-Type", "application/x-ndjson")
+		// json.Encoder terminates every document with a newline.
+		enc := json.NewEncoder(c.Writer)
+		err := documents(func(_ int, doc interface{}) error {
+			return enc.Encode(doc)
+		})
+		if err != nil {
+			log.Printf("Error encoding ODPS31 dump: %v", err)
+		}
+	default:
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		enc := yaml.NewEncoder(c.Writer)
+		defer enc.Close()
+		err := documents(func(_ int, doc interface{}) error {
+			return enc.Encode(doc)
+		})
+		if err != nil {
+			log.Printf("Error encoding ODPS31 dump: %v", err)
+		}
+	}
+}
@@ -10,12 +10,16 @@ import (
 	"opendatahub.com/dataset-catalog-api/transformers"
 )
 
-func ODPSGinHandler(c *gin.Context) {
-	ds, err := fetchDatasets(1)
-	if err != nil || len(ds) == 0 {
-		c.String(http.StatusNotFound, "No data found")
+func (s *Server) ODPSGinHandler(c *gin.Context) {
+	resp, err := s.catalog.Page(c.Request.Context(), 1, getPageSize(c.Request), upstreamFilters(c.Request), c.Query("deprecated"))
+	if err != nil {
+		upstreamUnavailable(c)
 		return
 	}
-	output := transformers.ToODPS(ConvertDatasets(ds))
-	c.JSON(http.StatusOK, output)
+	if resp == nil {
+		problem(c, http.StatusNotFound, "No data found")
+		return
+	}
+	output := transformers.ToODPS(s.publisher(c), resp.Items, getLanguage(c.Request))
+	s.render(c, output, "json", resp.Items...)
 }
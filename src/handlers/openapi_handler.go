@@ -0,0 +1,75 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DatasetOpenAPIGinHandler serves GET /datasets/:uuid/openapi, the OpenAPI document
+// referenced by the dataset's SwaggerUrl with its server URLs pointed at the dataset API.
+// Default output is JSON; use ?format=yaml or ?format=toml for other formats.
+func (s *Server) DatasetOpenAPIGinHandler(c *gin.Context) {
+	datasetID := c.Param("uuid")
+	found := s.catalog.Dataset(c.Request.Context(), datasetID)
+	if found == nil {
+		problem(c, http.StatusNotFound, "Dataset not found")
+		return
+	}
+	if found.SwaggerUrl == "" {
+		problem(c, http.StatusNotFound, "No OpenAPI document available")
+		return
+	}
+
+	spec, err := s.catalog.OpenAPISpec(c.Request.Context(), datasetID, found.SwaggerUrl)
+	if err != nil {
+		log.Printf("Error fetching OpenAPI document for ID %s: %v", datasetID, err)
+		problem(c, http.StatusBadGateway, "Error fetching OpenAPI document")
+		return
+	}
+	s.renderVerbatim(c, withServer(spec, apiServerURL(found.BaseUrl, found.ApiUrl)), "json")
+}
+
+// apiServerURL returns the scheme and host the dataset API is served from,
+// preferring BaseUrl and falling back to the origin of ApiUrl.
+func apiServerURL(baseURL, apiURL string) string {
+	if baseURL != "" {
+		return baseURL
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// withServer returns a shallow copy of spec whose server information points at
+// server: "servers" for OpenAPI 3 documents, "host"/"basePath"/"schemes" for Swagger 2.
+func withServer(spec map[string]interface{}, server string) map[string]interface{} {
+	out := make(map[string]interface{}, len(spec))
+	for k, v := range spec {
+		out[k] = v
+	}
+	if server == "" {
+		return out
+	}
+	if _, isSwagger2 := spec["swagger"]; isSwagger2 {
+		u, err := url.Parse(server)
+		if err != nil {
+			return out
+		}
+		out["host"] = u.Host
+		out["schemes"] = []string{u.Scheme}
+		if u.Path != "" {
+			out["basePath"] = u.Path
+		}
+		return out
+	}
+	out["servers"] = []map[string]interface{}{{"url": server}}
+	return out
+}
@@ -0,0 +1,61 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPISpecGinHandler serves /openapi.json, an OpenAPI 3 description of this
+// service generated from the route registry.
+func (s *Server) OpenAPISpecGinHandler(c *gin.Context) {
+	paths := map[string]interface{}{}
+	for _, r := range s.Routes {
+		var params []map[string]interface{}
+		// Convert gin's :param segments to OpenAPI {param} templates.
+		segments := strings.Split(r.Path, "/")
+		for i, seg := range segments {
+			if strings.HasPrefix(seg, ":") {
+				name := seg[1:]
+				segments[i] = "{" + name + "}"
+				params = append(params, map[string]interface{}{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]string{"type": "string"},
+				})
+			}
+		}
+		if len(r.Formats) > 0 {
+			params = append(params, map[string]interface{}{
+				"name":   "format",
+				"in":     "query",
+				"schema": map[string]interface{}{"type": "string", "enum": r.Formats},
+			})
+		}
+		operation := map[string]interface{}{
+			"summary": r.Description,
+			"responses": map[string]interface{}{
+				"200":     map[string]string{"description": "OK"},
+				"default": map[string]string{"description": "Problem details (RFC 7807)"},
+			},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		paths[strings.Join(segments, "/")] = map[string]interface{}{"get": operation}
+	}
+
+	s.renderVerbatim(c, map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   s.publisher(c).Name + " Dataset Catalog API",
+			"version": Version,
+		},
+		"servers": []map[string]string{{"url": strings.TrimSuffix(s.publisher(c).BaseURL, "/")}},
+		"paths":   paths,
+	}, "json")
+}
@@ -0,0 +1,123 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// outputSchema lists the fields a schema requires of the document an output
+// format builds for one dataset. In the field paths, * stands for any key,
+// such as the language keys of the ODPS product.
+type outputSchema struct {
+	name     string
+	build    func(p transformers.Publisher, ds transformers.Dataset) interface{}
+	required []string
+}
+
+// outputSchemas are the schemas ValidateCatalog checks every dataset against:
+// the mandatory properties of a DCAT-AP dataset and the required fields of the
+// ODPS 3.0 and 3.1 schemas.
+var outputSchemas = []outputSchema{
+	{
+		name: "dcat",
+		build: func(p transformers.Publisher, ds transformers.Dataset) interface{} {
+			return transformers.ToDCATDataset(p, ds, transformers.DefaultLanguage)
+		},
+		required: []string{"@id", "@type", "dct:identifier", "dct:title", "dct:description"},
+	},
+	{
+		name: "odps30",
+		build: func(p transformers.Publisher, ds transformers.Dataset) interface{} {
+			return transformers.ToODPS30(p, []transformers.Dataset{ds}, transformers.DefaultLanguage)
+		},
+		required: []string{"schema", "version", "product.*.name", "product.*.productID", "product.*.visibility", "product.*.status", "product.*.type"},
+	},
+	{
+		name: "odps31",
+		build: func(p transformers.Publisher, ds transformers.Dataset) interface{} {
+			return transformers.ToODPS31(p, []transformers.Dataset{ds}, transformers.DefaultLanguage)
+		},
+		required: []string{"schema", "version", "product.*.name", "product.*.productID", "product.*.visibility", "product.*.status", "product.*.type", "product.dataAccess.type", "product.dataHolder.URL"},
+	},
+}
+
+// ValidateCatalog builds every output of outputSchemas for every dataset of
+// the catalog and writes a line to w for each required field that is missing
+// or empty. It returns the number of datasets checked and of problems found.
+func (s *Server) ValidateCatalog(ctx context.Context, w io.Writer) (int, int, error) {
+	p := s.cfg.Publisher
+	checked, problems := 0, 0
+	err := s.catalog.ForEachPage(ctx, func(items []transformers.Dataset) error {
+		for _, ds := range items {
+			checked++
+			for _, schema := range outputSchemas {
+				doc, err := genericDocument(schema.build(p, ds))
+				if err != nil {
+					return err
+				}
+				for _, field := range schema.required {
+					if !hasField(doc, strings.Split(field, ".")) {
+						problems++
+						fmt.Fprintf(w, "%s: %s: missing %s\n", ds.ID, schema.name, field)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	return checked, problems, err
+}
+
+// genericDocument returns doc as decoded from its JSON encoding, so that the
+// typed documents (DCAT) and the map-based ones (ODPS) are checked alike.
+func genericDocument(doc interface{}) (interface{}, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+// hasField reports whether doc, as returned by genericDocument, has a
+// non-empty value at path. An object is empty if all its values are.
+func hasField(doc interface{}, path []string) bool {
+	if len(path) == 0 {
+		switch v := doc.(type) {
+		case nil:
+			return false
+		case string:
+			return v != ""
+		case map[string]interface{}:
+			for _, item := range v {
+				if hasField(item, nil) {
+					return true
+				}
+			}
+			return false
+		}
+		return true
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if path[0] == "*" {
+		for _, v := range m {
+			if hasField(v, path[1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return hasField(m[path[0]], path[1:])
+}
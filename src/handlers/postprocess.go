@@ -0,0 +1,195 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// postProcessor rewrites a transformer output in its generic form, in which
+// objects are map[string]interface{} and arrays []interface{}, and returns it.
+type postProcessor func(v interface{}) interface{}
+
+// postProcessors holds every post-processing step, by name. Steps are given as
+// name or name:arg in OUTPUT_POSTPROCESS and in the postProcess list of a
+// publisher profile. Adding an entry here makes the step available to both.
+var postProcessors = map[string]func(arg string) (postProcessor, error){
+	// The generic form has no key order, so every encoder sorts the keys of
+	// any post-processed output; sortKeys does nothing else.
+	"sortKeys": withoutArg(func(v interface{}) interface{} { return v }),
+	"stripNulls": withoutArg(func(v interface{}) interface{} {
+		return stripMembers(v, func(member interface{}) bool { return member == nil })
+	}),
+	"stripEmpty": withoutArg(func(v interface{}) interface{} {
+		return stripMembers(v, isEmptyMember)
+	}),
+	"omit": func(field string) (postProcessor, error) {
+		if field == "" {
+			return nil, errors.New("omit needs a field name, e.g. omit:links")
+		}
+		return func(v interface{}) interface{} {
+			walkObjects(v, func(obj map[string]interface{}) { delete(obj, field) })
+			return v
+		}, nil
+	},
+}
+
+func withoutArg(step postProcessor) func(arg string) (postProcessor, error) {
+	return func(arg string) (postProcessor, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+		return step, nil
+	}
+}
+
+// walkObjects calls fn with every object in v, innermost first, so members
+// emptied by fn are seen empty by the objects containing them.
+func walkObjects(v interface{}, fn func(obj map[string]interface{})) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, member := range v {
+			walkObjects(member, fn)
+		}
+		fn(v)
+	case []interface{}:
+		for _, item := range v {
+			walkObjects(item, fn)
+		}
+	}
+}
+
+// stripMembers removes the members of every object in v that drop reports.
+func stripMembers(v interface{}, drop func(member interface{}) bool) interface{} {
+	walkObjects(v, func(obj map[string]interface{}) {
+		for key, member := range obj {
+			if drop(member) {
+				delete(obj, key)
+			}
+		}
+	})
+	return v
+}
+
+// isEmptyMember reports whether member is null, an empty string, array or object.
+func isEmptyMember(member interface{}) bool {
+	switch member := member.(type) {
+	case nil:
+		return true
+	case string:
+		return member == ""
+	case []interface{}:
+		return len(member) == 0
+	case map[string]interface{}:
+		return len(member) == 0
+	}
+	return false
+}
+
+// pipeline is a sequence of post-processing steps.
+type pipeline []postProcessor
+
+// parsePipeline returns the pipeline of the steps given as name or name:arg.
+func parsePipeline(steps []string) (pipeline, error) {
+	var out pipeline
+	for _, s := range steps {
+		name, arg, _ := strings.Cut(s, ":")
+		newStep, ok := postProcessors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processing step %q", name)
+		}
+		step, err := newStep(arg)
+		if err != nil {
+			return nil, fmt.Errorf("post-processing step %q: %w", s, err)
+		}
+		out = append(out, step)
+	}
+	return out, nil
+}
+
+// apply returns v processed by the pipeline. Without steps, v is returned as
+// is; otherwise the steps work on a copy of v in its generic form, so shared
+// documents, such as the memoized ones, are never modified.
+func (p pipeline) apply(v interface{}) (interface{}, error) {
+	if len(p) == 0 {
+		return v, nil
+	}
+	generic, err := genericForm(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range p {
+		generic = step(generic)
+	}
+	return generic, nil
+}
+
+// genericForm returns v as decoded from its JSON encoding, with integral
+// numbers as int64, so they keep their type in the YAML and TOML outputs.
+func genericForm(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return convertNumbers(out), nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, member := range v {
+			v[key] = convertNumbers(member)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+// LoadPostProcessing loads OUTPUT_POSTPROCESS, a comma-separated list of the
+// post-processing steps applied to every JSON, YAML and TOML output, e.g.
+// stripNulls,omit:links. Publisher profiles may add their own steps.
+func (s *Server) LoadPostProcessing() error {
+	steps := s.cfg.OutputPostProcess
+	loaded, err := parsePipeline(steps)
+	if err != nil {
+		return err
+	}
+	s.outputPipeline = loaded
+	if len(loaded) > 0 {
+		log.Printf("Post-processing outputs with %s", strings.Join(steps, ", "))
+	}
+	return nil
+}
+
+// postProcess returns the output v processed by the global pipeline and that
+// of the publisher profile of ctx.
+func (s *Server) postProcess(ctx context.Context, v interface{}) (interface{}, error) {
+	profile := s.publisherProfileFrom(ctx)
+	if len(profile.pipeline) == 0 {
+		return s.outputPipeline.apply(v)
+	}
+	steps := append(append(pipeline{}, s.outputPipeline...), profile.pipeline...)
+	return steps.apply(v)
+}
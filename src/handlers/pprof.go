@@ -0,0 +1,44 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pprofEnabled reports whether PPROF_ENABLED is set, which serves the Go
+// runtime profiles at /debug/pprof/.
+func (s *Server) pprofEnabled() bool {
+	return s.cfg.Server.PprofEnabled
+}
+
+// registerPprof registers /debug/pprof/, protected by the admin token like
+// the /admin endpoints, so CPU, heap, goroutine and other profiles can be
+// taken from a running instance with go tool pprof.
+func (s *Server) registerPprof(router *gin.Engine) {
+	debug := router.Group("/debug/pprof", cacheControlMiddleware("no-store"), s.AdminAuthMiddleware())
+	debug.GET("/*name", PprofGinHandler)
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+}
+
+// PprofGinHandler serves GET /debug/pprof/{name}: the index of profiles, the
+// named runtime profiles (heap, goroutine, allocs, ...), a CPU profile
+// (profile?seconds=n), an execution trace (trace?seconds=n), the command
+// line and symbol lookups.
+func PprofGinHandler(c *gin.Context) {
+	switch c.Param("name") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,119 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type prerenderKey struct{}
+
+// setPrerenderRouter makes prerenderResponses serve its requests with router,
+// and runs it if the first sync completed before the routes were registered.
+func (s *Server) setPrerenderRouter(router *gin.Engine) {
+	s.prerenderRouter.Store(router)
+	if status, _ := s.catalog.CurrentSyncStatus(); !status.LastSuccess.IsZero() {
+		go s.prerenderResponses(status.LastSuccess, true)
+	}
+}
+
+// prerendering reports whether ctx is that of a prerenderResponses request,
+// and whether it refreshes the response, which the response cache then
+// renders even if it holds one.
+func prerendering(ctx context.Context) (prerender, refresh bool) {
+	refresh, prerender = ctx.Value(prerenderKey{}).(bool)
+	return prerender, refresh
+}
+
+// prerenderResponses refreshes the response cache after a catalog sync: it
+// renders the first page of every Prerender route in its default format and
+// in each of its formats into the cache, so the hottest requests are served
+// from memory, then drops the responses cached before the sync. The responses
+// are kept until the next sync replaces them. If the sync found the catalog
+// unchanged, the cached responses, rendered from the same data, are kept
+// until the next sync as well, with keepResponses, and only the missing
+// ones are rendered.
+func (s *Server) prerenderResponses(started time.Time, changed bool) {
+	router, ok := s.prerenderRouter.Load().(http.Handler)
+	if !ok {
+		return
+	}
+	if !changed {
+		s.keepResponses()
+	}
+	ctx := context.WithValue(context.Background(), prerenderKey{}, changed)
+	for _, uri := range s.prerenderPaths {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			continue
+		}
+		req.RemoteAddr = "127.0.0.1:0"
+		w := &discardResponse{header: http.Header{}}
+		router.ServeHTTP(w, req)
+		if w.status != http.StatusOK {
+			log.Printf("Prerendering %s failed with status %d", uri, w.status)
+		}
+	}
+
+	if !changed {
+		return
+	}
+	s.responseMutex.Lock()
+	dropped := 0
+	for key, item := range s.responseCache {
+		if item.created.Before(started) {
+			delete(s.responseCache, key)
+			dropped++
+		}
+	}
+	s.responseMutex.Unlock()
+	s.catalog.CountEvictions("responses", dropped)
+	log.Printf("Prerendered %d responses, dropped %d outdated ones", len(s.prerenderPaths), dropped)
+}
+
+// keepResponses extends the cached responses to the expiration of prerendered
+// ones, after a sync found the catalog they were rendered from unchanged, so
+// they are not rendered again. Responses of ExternalData routes expire as
+// usual.
+func (s *Server) keepResponses() {
+	expiration := s.catalog.SyncExpiration()
+	s.responseMutex.Lock()
+	kept := 0
+	for key, item := range s.responseCache {
+		if !item.external && item.expiration.Before(expiration) {
+			item.expiration = expiration
+			s.responseCache[key] = item
+			kept++
+		}
+	}
+	s.responseMutex.Unlock()
+	log.Printf("Catalog unchanged, keeping %d cached responses", kept)
+}
+
+// discardResponse is the http.ResponseWriter of prerendered requests, whose
+// bodies only end up in the response cache.
+type discardResponse struct {
+	header http.Header
+	status int
+}
+
+func (w *discardResponse) Header() http.Header { return w.header }
+
+func (w *discardResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (w *discardResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
@@ -0,0 +1,73 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	requestIDKey       = "requestID"
+	problemContentType = "application/problem+json"
+	problemTypeDefault = "about:blank"
+)
+
+// RequestIDMiddleware assigns every request an ID, reusing an incoming
+// X-Request-ID header when present, and echoes it in the response. The ID and
+// an incoming W3C traceparent header are kept in the request context, to be
+// passed on to the upstream API (see catalog.WithRequestID).
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			b := make([]byte, 16)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		ctx := catalog.WithRequestID(c.Request.Context(), id, c.GetHeader("traceparent"))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// NotFoundGinHandler answers requests to unknown routes with a problem response.
+func NotFoundGinHandler(c *gin.Context) {
+	problem(c, http.StatusNotFound, "No such endpoint")
+}
+
+// upstreamUnavailableDetail is the detail of the responses of requests whose
+// data could neither be fetched from the upstream API nor served from
+// last-known-good data.
+const upstreamUnavailableDetail = "Upstream API unavailable"
+
+// upstreamUnavailable answers such a request with 503 Service Unavailable.
+func upstreamUnavailable(c *gin.Context) {
+	problem(c, http.StatusServiceUnavailable, upstreamUnavailableDetail)
+}
+
+// problem writes an RFC 7807 application/problem+json error response and aborts
+// the request.
+func problem(c *gin.Context, status int, detail string) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"type":      problemTypeDefault,
+		"title":     http.StatusText(status),
+		"status":    status,
+		"detail":    detail,
+		"instance":  c.Request.URL.RequestURI(),
+		"requestId": c.GetString(requestIDKey),
+	})
+	// Errors must not be kept by shared caches.
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, problemContentType, body)
+	c.Abort()
+}
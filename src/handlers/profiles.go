@@ -0,0 +1,196 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// publisherProfile is one branded catalog served by the deployment: the
+// requests it answers, selected by hostname or path prefix, the publisher
+// shown in its documents, the dataspaces it is limited to and the
+// post-processing steps of its outputs.
+type publisherProfile struct {
+	Name        string                 `yaml:"name"`
+	Hosts       []string               `yaml:"hosts"`
+	PathPrefix  string                 `yaml:"pathPrefix"`
+	Dataspaces  catalog.Dataspaces     `yaml:"dataspaces"`
+	Publisher   transformers.Publisher `yaml:"publisher"`
+	PostProcess []string               `yaml:"postProcess"`
+
+	// pipeline is parsed from PostProcess by normalize.
+	pipeline pipeline
+}
+
+type publisherProfileContextKey struct{}
+
+// LoadProfiles loads the publisher profiles from the YAML file at path, a list
+// of profiles with a name, hosts and/or a pathPrefix, the dataspaces to serve
+// (all if empty), the publisher metadata and the post-processing steps added
+// to those of OUTPUT_POSTPROCESS. Publisher fields that are not set
+// are taken from the default publisher; the base URL defaults to the default
+// one plus the path prefix, or to https://{first host}/.
+func (s *Server) LoadProfiles(path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var nodes []yaml.Node
+	if err := yaml.Unmarshal(body, &nodes); err != nil {
+		return err
+	}
+	var loaded []*publisherProfile
+	names := map[string]bool{}
+	for i := range nodes {
+		p := &publisherProfile{Publisher: s.cfg.Publisher}
+		p.Publisher.BaseURL = ""
+		// Decoding would add to the maps of the default publisher, so a
+		// profile's title and description replace them as a whole.
+		p.Publisher.Title, p.Publisher.Description = nil, nil
+		if err := nodes[i].Decode(p); err != nil {
+			return fmt.Errorf("profile %d: %w", i+1, err)
+		}
+		if p.Publisher.Title == nil {
+			p.Publisher.Title = s.cfg.Publisher.Title
+		}
+		if p.Publisher.Description == nil {
+			p.Publisher.Description = s.cfg.Publisher.Description
+		}
+		if err := p.normalize(s.cfg.Publisher.BaseURL); err != nil {
+			return fmt.Errorf("profile %d: %w", i+1, err)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate profile %q", p.Name)
+		}
+		names[p.Name] = true
+		loaded = append(loaded, p)
+	}
+	s.publisherProfiles = loaded
+	log.Printf("Serving %d publisher profiles from %s", len(loaded), path)
+	return nil
+}
+
+// normalize validates a decoded profile and fills in its defaults; the base
+// URL is derived from defaultBaseURL, that of the default publisher.
+func (p *publisherProfile) normalize(defaultBaseURL string) error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Hosts) == 0 && p.PathPrefix == "" {
+		return errors.New("hosts or pathPrefix is required")
+	}
+	for i, host := range p.Hosts {
+		p.Hosts[i] = strings.ToLower(host)
+	}
+	if p.PathPrefix != "" {
+		p.PathPrefix = "/" + strings.Trim(p.PathPrefix, "/")
+		if p.PathPrefix == "/" {
+			return errors.New("pathPrefix must not be /")
+		}
+	}
+	if p.Publisher.BaseURL == "" {
+		if len(p.Hosts) > 0 {
+			p.Publisher.BaseURL = "https://" + p.Hosts[0] + p.PathPrefix + "/"
+		} else {
+			p.Publisher.BaseURL = strings.TrimSuffix(defaultBaseURL, "/") + p.PathPrefix + "/"
+		}
+	}
+	if !strings.HasSuffix(p.Publisher.BaseURL, "/") {
+		p.Publisher.BaseURL += "/"
+	}
+	var err error
+	p.pipeline, err = parsePipeline(p.PostProcess)
+	return err
+}
+
+// matches reports whether the profile serves a request for host and path.
+func (p *publisherProfile) matches(host, path string) bool {
+	if len(p.Hosts) > 0 && !containsFold(p.Hosts, host) {
+		return false
+	}
+	return p.PathPrefix == "" || path == p.PathPrefix || strings.HasPrefix(path, p.PathPrefix+"/")
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileHandler wraps next to select the publisher profile of each request by
+// its Host header or path prefix. The prefix is stripped, so the profile
+// serves the same routes as the default catalog under it. Requests matching no
+// profile are served by the default publisher with the whole catalog.
+//
+// Requests from trusted proxies are matched by their forwarded host, and
+// their links are built from the forwarded scheme and host.
+func (s *Server) ProfileHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, forwardedHost, forwarded := s.forwardedOrigin(r)
+		host := r.Host
+		if forwardedHost != "" {
+			host = forwardedHost
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		var profile *publisherProfile
+		for _, p := range s.publisherProfiles {
+			if !p.matches(host, r.URL.Path) {
+				continue
+			}
+			if p.PathPrefix != "" {
+				r.URL.Path = strings.TrimPrefix(r.URL.Path, p.PathPrefix)
+				if r.URL.Path == "" {
+					r.URL.Path = "/"
+				}
+				r.URL.RawPath = ""
+			}
+			profile = p
+			break
+		}
+		if forwarded {
+			rebased := publisherProfile{Publisher: s.cfg.Publisher}
+			if profile != nil {
+				rebased = *profile
+			}
+			rebased.Publisher.BaseURL = rebaseURL(rebased.Publisher.BaseURL, scheme, forwardedHost)
+			profile = &rebased
+		}
+		if profile != nil {
+			ctx := context.WithValue(r.Context(), publisherProfileContextKey{}, profile)
+			r = r.WithContext(catalog.WithDataspaces(ctx, profile.Dataspaces))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publisherProfileFrom returns the publisher profile of a request context, or
+// the default profile if none was selected.
+func (s *Server) publisherProfileFrom(ctx context.Context) *publisherProfile {
+	if p, ok := ctx.Value(publisherProfileContextKey{}).(*publisherProfile); ok {
+		return p
+	}
+	return &publisherProfile{Publisher: s.cfg.Publisher}
+}
+
+// publisher returns the publisher of the catalog a request is served from.
+func (s *Server) publisher(c *gin.Context) transformers.Publisher {
+	return s.publisherProfileFrom(c.Request.Context()).Publisher
+}
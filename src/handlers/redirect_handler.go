@@ -0,0 +1,47 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RedirectGinHandler serves GET /go/:uuid, redirecting to the dataset's ApiUrl,
+// or to its SwaggerUrl with ?to=docs, and counting the clicks per dataset.
+func (s *Server) RedirectGinHandler(c *gin.Context) {
+	datasetID := c.Param("uuid")
+	found := s.catalog.Dataset(c.Request.Context(), datasetID)
+	if found == nil {
+		problem(c, http.StatusNotFound, "Dataset not found")
+		return
+	}
+
+	target := found.ApiUrl
+	if c.Query("to") == "docs" {
+		target = found.SwaggerUrl
+	}
+	if target == "" {
+		problem(c, http.StatusNotFound, "No link available")
+		return
+	}
+
+	s.clickMutex.Lock()
+	s.clickCounts[found.ID]++
+	s.clickMutex.Unlock()
+	c.Redirect(http.StatusFound, target)
+}
+
+// RedirectStatsGinHandler serves GET /go/stats, the click counts per dataset ID
+// since the service started.
+func (s *Server) RedirectStatsGinHandler(c *gin.Context) {
+	s.clickMutex.Lock()
+	counts := make(map[string]int, len(s.clickCounts))
+	for id, n := range s.clickCounts {
+		counts[id] = n
+	}
+	s.clickMutex.Unlock()
+	s.renderVerbatim(c, map[string]interface{}{"clicks": counts}, "json")
+}
@@ -0,0 +1,124 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// renderer serializes a transformer output into a response body.
+type renderer struct {
+	contentType string
+	encode      func(w io.Writer, v interface{}) error
+	// encodeDatasets, when set, renders the underlying datasets instead of the
+	// transformer output. Such formats are only offered by endpoints that pass datasets.
+	encodeDatasets func(w io.Writer, p transformers.Publisher, datasets []transformers.Dataset) error
+	// processed formats serialize the output after post-processing, see
+	// postProcess. The others need the documents as built by the transformers.
+	processed bool
+}
+
+// newRenderers returns every output format selectable via the "format" query
+// parameter. Adding an entry here makes the format available on all catalog
+// endpoints.
+func newRenderers() map[string]renderer {
+	return map[string]renderer{
+		"json": {contentType: "application/json; charset=utf-8", encode: encodeJSON, processed: true},
+		"yaml": {contentType: "text/plain; charset=utf-8", encode: encodeYAML, processed: true},
+		"toml": {contentType: "application/toml; charset=utf-8", encode: encodeTOML, processed: true},
+		"md":   {contentType: "text/markdown; charset=utf-8", encodeDatasets: encodeMarkdown},
+		"ttl":  {contentType: "text/turtle; charset=utf-8", encode: encodeTurtle},
+	}
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeYAML(w io.Writer, v interface{}) error {
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func encodeTOML(w io.Writer, v interface{}) error {
+	return toml.NewEncoder(w).Encode(v)
+}
+
+// encodeTurtle serializes JSON-LD outputs (DCAT, VoID) as Turtle.
+func encodeTurtle(w io.Writer, v interface{}) error {
+	ttl, err := transformers.ToTurtle(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, ttl)
+	return err
+}
+
+func encodeMarkdown(w io.Writer, p transformers.Publisher, datasets []transformers.Dataset) error {
+	_, err := io.WriteString(w, transformers.ToMarkdown(p, datasets))
+	return err
+}
+
+// render writes output in the format requested via ?format=, falling back to
+// defaultFormat when the parameter is missing, unknown or not applicable.
+// datasets are the datasets output was built from, used by dataset-level formats.
+// The output is post-processed, see postProcess.
+func (s *Server) render(c *gin.Context, output interface{}, defaultFormat string, datasets ...transformers.Dataset) {
+	s.renderOutput(c, output, defaultFormat, true, datasets)
+}
+
+// renderVerbatim is render without post-processing, for outputs that are not
+// catalog documents, such as OpenAPI descriptions.
+func (s *Server) renderVerbatim(c *gin.Context, output interface{}, defaultFormat string) {
+	s.renderOutput(c, output, defaultFormat, false, nil)
+}
+
+func (s *Server) renderOutput(c *gin.Context, output interface{}, defaultFormat string, process bool, datasets []transformers.Dataset) {
+	format := c.Query("format")
+	r, ok := s.renderers[format]
+	if !ok || (r.encodeDatasets != nil && len(datasets) == 0) {
+		format = defaultFormat
+		r, ok = s.renderers[format]
+	}
+	if !ok {
+		// The default format is disabled in DISABLED_FORMATS.
+		format, r = "json", s.renderers["json"]
+	}
+	if process && r.processed {
+		var err error
+		if output, err = s.postProcess(c.Request.Context(), output); err != nil {
+			problem(c, http.StatusInternalServerError, "Error processing "+strings.ToUpper(format))
+			return
+		}
+	}
+	encode := func(w io.Writer) error {
+		return r.encode(w, output)
+	}
+	if r.encodeDatasets != nil {
+		p := s.publisher(c)
+		encode = func(w io.Writer) error {
+			return r.encodeDatasets(w, p, datasets)
+		}
+	}
+	err := s.writeEncoded(c, r.contentType, encode)
+	if errors.Is(err, transformers.ErrNotJSONLD) {
+		problem(c, http.StatusNotAcceptable, "Format "+format+" is not available for this endpoint")
+		return
+	}
+	if err != nil {
+		problem(c, http.StatusInternalServerError, "Error marshaling "+strings.ToUpper(format))
+	}
+}
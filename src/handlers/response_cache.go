@@ -0,0 +1,225 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// maxResponseEntries bounds the number of cached responses.
+const maxResponseEntries = 1000
+
+// responseItem is a cached, already serialized response body.
+type responseItem struct {
+	contentType string
+	body        []byte
+	created     time.Time
+	expiration  time.Time
+	// external marks responses of ExternalData routes.
+	external bool
+}
+
+// size returns the memory held by the response, counted against
+// CACHE_MEMORY_BUDGET.
+func (item responseItem) size() int {
+	return len(item.body) + len(item.contentType)
+}
+
+// registerResponseCache manages the response cache with the caches of the
+// catalog, and prerenders it after every sync.
+func (s *Server) registerResponseCache() {
+	s.catalog.RegisterCache(catalog.Cache{
+		Name:          "responses",
+		Expirations:   s.responseExpirations,
+		Bytes:         s.responseBytes,
+		Evict:         s.evictResponses,
+		RemoveExpired: s.removeExpiredResponses,
+		Flush:         s.flushResponses,
+	})
+	s.catalog.OnSync(s.prerenderResponses)
+}
+
+// bodyRecorder holds back the status and body a handler writes until the
+// handler returns, so the response cache can add the headers that depend on
+// the whole body, and keep that body without copying it.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) WriteHeaderNow() {}
+
+func (w *bodyRecorder) Flush() {}
+
+func (w *bodyRecorder) Written() bool {
+	return w.body.Len() > 0
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// send writes the recorded status and body as they are.
+func (w *bodyRecorder) send() {
+	w.ResponseWriter.WriteHeaderNow()
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+// responseKey identifies a response by publisher profile and base URL, endpoint, query
+// parameters (format, page, ...) and the language negotiated from ?lang= or
+// Accept-Language.
+func (s *Server) responseKey(c *gin.Context) string {
+	profile := s.publisherProfileFrom(c.Request.Context())
+	return profile.Name + "|" + profile.Publisher.BaseURL + "|" + c.Request.URL.Path + "?" + c.Request.URL.Query().Encode() + "|" + getLanguage(c.Request)
+}
+
+// responseCacheMiddleware serves successful responses from a cache of
+// serialized bodies for 5 minutes, or until a sync changes the catalog, so hot
+// requests skip fetching, transformation and serialization. Responses built
+// from cached pages or details expiring sooner are kept only until those
+// expire, and not at all if they are expired already. Responses report
+// X-Cache: HIT or MISS, and cached ones their Age; max-age is aligned with
+// their lifetime. external marks the responses of ExternalData routes.
+func (s *Server) responseCacheMiddleware(external bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := s.responseKey(c)
+		s.responseMutex.RLock()
+		item, found := s.responseCache[key]
+		s.responseMutex.RUnlock()
+		prerender, refresh := prerendering(c.Request.Context())
+		if found && !refresh && time.Now().Before(item.expiration) {
+			s.catalog.CountHit("responses")
+			c.Header("X-Cache", "HIT")
+			alignMaxAge(c, item.created, item.expiration)
+			s.writeData(c, item.contentType, item.body)
+			c.Abort()
+			return
+		}
+
+		s.catalog.CountMiss("responses")
+		c.Header("X-Cache", "MISS")
+		c.Request = c.Request.WithContext(catalog.WithFreshness(c.Request.Context()))
+		created := time.Now()
+		expiration := created.Add(catalog.CacheTTL)
+		rec := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		c.Writer = rec.ResponseWriter
+		// Failed responses, and bodies cut short by an encoding error, are
+		// sent as written and not cached.
+		if rec.Status() != http.StatusOK || len(c.Errors) > 0 {
+			rec.send()
+			return
+		}
+		if prerender {
+			// Prerendered responses are kept until the next sync, which
+			// refreshes the data they are built from.
+			expiration = s.catalog.SyncExpiration()
+		} else if data, ok := catalog.DataExpiration(c.Request.Context()); ok && data.Before(expiration) {
+			expiration = data
+			if expiration.Before(created) {
+				expiration = created
+			}
+		}
+		alignMaxAge(c, created, expiration)
+		contentType := rec.Header().Get("Content-Type")
+		s.writeData(c, contentType, rec.body.Bytes())
+		// Responses possibly built from last-known-good data are not cached,
+		// so clients get fresh data as soon as the upstream API recovers.
+		if s.catalog.UpstreamFailing() || !expiration.After(created) {
+			return
+		}
+		s.storeResponse(key, responseItem{
+			contentType: contentType,
+			body:        rec.body.Bytes(),
+			created:     created,
+			expiration:  expiration,
+			external:    external,
+		})
+	}
+}
+
+// storeResponse caches item under key. Expired entries are dropped whenever
+// the cache is full; if it is still full, item is not cached.
+func (s *Server) storeResponse(key string, item responseItem) {
+	defer s.catalog.EnforceCacheBudget()
+	s.responseMutex.Lock()
+	defer s.responseMutex.Unlock()
+	now := time.Now()
+	if len(s.responseCache) >= maxResponseEntries {
+		for k, v := range s.responseCache {
+			if now.After(v.expiration) {
+				delete(s.responseCache, k)
+				s.catalog.CountEvictions("responses", 1)
+			}
+		}
+	}
+	if len(s.responseCache) < maxResponseEntries {
+		s.responseCache[key] = item
+	}
+}
+
+func (s *Server) responseExpirations() []time.Time {
+	s.responseMutex.RLock()
+	defer s.responseMutex.RUnlock()
+	out := make([]time.Time, 0, len(s.responseCache))
+	for _, item := range s.responseCache {
+		out = append(out, item.expiration)
+	}
+	return out
+}
+
+func responseEntry(item responseItem) (int, time.Time) { return item.size(), item.expiration }
+
+func (s *Server) responseBytes() int64 {
+	s.responseMutex.RLock()
+	defer s.responseMutex.RUnlock()
+	var total int64
+	for _, item := range s.responseCache {
+		total += int64(item.size())
+	}
+	return total
+}
+
+func (s *Server) evictResponses(want int64) (int64, int) {
+	s.responseMutex.Lock()
+	defer s.responseMutex.Unlock()
+	return catalog.EvictEarliest(s.responseCache, responseEntry, want)
+}
+
+func (s *Server) removeExpiredResponses(now time.Time) int {
+	s.responseMutex.Lock()
+	defer s.responseMutex.Unlock()
+	removed := 0
+	for key, item := range s.responseCache {
+		if item.expiration.Before(now) {
+			delete(s.responseCache, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// flushResponses drops every cached response, as any of them may contain the
+// flushed data, and makes the next ODPS 3.1 dump request regenerate it.
+func (s *Server) flushResponses(page int, id string) int {
+	s.responseMutex.Lock()
+	n := len(s.responseCache)
+	clear(s.responseCache)
+	s.responseMutex.Unlock()
+	s.odps31Dump.Expire()
+	return n
+}
@@ -0,0 +1,138 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Route describes a GET endpoint of the catalog. Routes without path
+// parameters are listed on the index page.
+type Route struct {
+	Path        string
+	Handler     gin.HandlerFunc
+	Description string
+	// Formats are the ?format= values the route accepts, offered as links on
+	// the index page for routes without path parameters. Besides the
+	// renderers, they may include formats the handler writes itself, such as
+	// the ndjson stream of a dump. Routes without Formats reject ?format=.
+	Formats []string
+	// ShowCount displays the number of datasets in the catalog on the index page.
+	ShowCount bool
+	// CacheResponse serves successful responses from the serialized response cache.
+	CacheResponse bool
+	// ExternalData marks cached routes whose responses depend on more than
+	// the upstream catalog, such as the OpenAPI documents of dataset APIs.
+	// Their responses expire with the cache TTL even if a sync finds the
+	// catalog unchanged.
+	ExternalData bool
+	// Prerender renders the first page of a cached route in each of its
+	// Formats into the response cache after every scheduled sync.
+	Prerender bool
+	// CacheControl overrides the default Cache-Control policy, which lets
+	// shared caches keep responses for the cache TTL.
+	CacheControl string
+	// Experimental routes are only served when listed in ENABLED_ROUTES.
+	Experimental bool
+}
+
+// newRoutes returns the registry of all catalog endpoints, served by s.
+func (s *Server) newRoutes() []Route {
+	return []Route{
+		{Path: "/dcat", Handler: s.DcatGinHandler, Description: "DCAT catalog, paginated", Formats: []string{"json", "yaml", "toml", "ttl", "md"}, ShowCount: true, CacheResponse: true, Prerender: true},
+		{Path: "/dcat/dump", Handler: s.DcatDumpGinHandler, Description: "Complete DCAT catalog in one document", ShowCount: true},
+		{Path: "/catalog.jsonld", Handler: s.CatalogJSONLDGinHandler, Description: "Complete catalog as JSON-LD", ShowCount: true},
+		{Path: "/.well-known/dcat", Handler: s.CatalogJSONLDGinHandler, Description: "Complete catalog as JSON-LD (well-known path)"},
+		{Path: "/dcat/dataspace/:name", Handler: s.DcatDataspaceGinHandler, Description: "DCAT catalog of one dataspace", Formats: []string{"json", "yaml", "toml", "ttl", "md"}, CacheResponse: true},
+		{Path: "/odps", Handler: s.ODPSGinHandler, Description: "ODPS v1.0 catalog", Formats: []string{"json", "yaml", "toml", "md"}, ShowCount: true, CacheResponse: true, Prerender: true},
+		{Path: "/odps30", Handler: s.ODPS30GinHandler, Description: "ODPS v3.0 (dev) dataset endpoints, paginated", Formats: []string{"yaml", "json", "toml", "md"}, ShowCount: true, CacheResponse: true, Prerender: true},
+		{Path: "/odps30/:uuid", Handler: s.ODPS30DetailGinHandler, Description: "ODPS v3.0 (dev) document of a dataset", Formats: []string{"yaml", "json", "toml", "md"}, CacheResponse: true},
+		{Path: "/odps31", Handler: s.ODPS31GinHandler, Description: "ODPS v3.1 dataset endpoints, paginated", Formats: []string{"yaml", "json", "toml", "md"}, ShowCount: true, CacheResponse: true, Prerender: true},
+		{Path: "/odps31/dump", Handler: s.ODPS31DumpGinHandler, Description: "ODPS v3.1 documents of every dataset", Formats: []string{"yaml", "json", "ndjson"}, ShowCount: true},
+		{Path: "/odps31/:uuid", Handler: s.ODPS31DetailGinHandler, Description: "ODPS v3.1 document of a dataset", Formats: []string{"yaml", "json", "toml", "md"}, CacheResponse: true},
+		{Path: "/sitemap.xml", Handler: s.SitemapGinHandler, Description: "Sitemap of all dataset pages", CacheResponse: true},
+		{Path: "/.well-known/void", Handler: s.VoIDGinHandler, Description: "VoID description of the catalog", Formats: []string{"json", "yaml", "toml", "ttl"}, CacheResponse: true},
+		{Path: "/jsonapi/datasets", Handler: s.JSONAPIDatasetsGinHandler, Description: "JSON:API datasets collection", ShowCount: true, CacheResponse: true, Prerender: true},
+		{Path: "/jsonapi/datasets/:uuid", Handler: s.JSONAPIDatasetGinHandler, Description: "JSON:API dataset resource", CacheResponse: true},
+		{Path: "/datasets/latest", Handler: s.LatestDatasetsGinHandler, Description: "Most recently changed datasets", Formats: []string{"json", "yaml", "toml", "md"}, CacheResponse: true},
+		{Path: "/datasets/:uuid", Handler: s.DatasetGinHandler, Description: "A dataset in any profile", Formats: []string{"json", "yaml", "toml", "ttl", "md", "html"}, CacheResponse: true},
+		{Path: "/datasets/:uuid/openapi", Handler: s.DatasetOpenAPIGinHandler, Description: "OpenAPI document of a dataset's API", Formats: []string{"json", "yaml", "toml"}, CacheResponse: true, ExternalData: true},
+		{Path: "/export/ndjson", Handler: s.NDJSONExportGinHandler, Description: "All datasets as JSON Lines", ShowCount: true},
+		{Path: "/facets", Handler: s.FacetsGinHandler, Description: "Distinct filter values and counts", Formats: []string{"json", "yaml", "toml"}, CacheResponse: true},
+		{Path: "/go/stats", Handler: s.RedirectStatsGinHandler, Description: "Shortlink click counts", Formats: []string{"json", "yaml", "toml"}, CacheControl: "no-cache"},
+		{Path: "/go/:uuid", Handler: s.RedirectGinHandler, Description: "Shortlink to a dataset's API", CacheControl: "no-store"},
+		{Path: "/version", Handler: s.VersionGinHandler, Description: "Build and version information"},
+		{Path: "/healthcheck", Handler: s.HealthcheckGinHandler, Description: "Liveness probe; ?deep=true also checks the dependencies", CacheControl: "no-store"},
+		{Path: "/ready", Handler: s.ReadyGinHandler, Description: "Readiness probe with the status of every dependency", CacheControl: "no-store"},
+		{Path: "/robots.txt", Handler: s.RobotsGinHandler, Description: "Crawler rules"},
+		{Path: "/.well-known/", Handler: s.WellKnownGinHandler, Description: "Discovery document for automated clients"},
+	}
+}
+
+// RegisterRoutes registers the index page, the OpenAPI description, the
+// Prometheus metrics (behind the admin token, unless they are served on
+// METRICS_ADDR), every route of the registry, the admin endpoints, the
+// profiling endpoints if PPROF_ENABLED is set and the fallback for unknown
+// paths. The first two are generated from the registry and
+// therefore not part of it; the others are operational endpoints. Routes and
+// formats switched off by applyFeatureFlags are left out; the index page, the
+// OpenAPI description and the metrics can be switched off in DISABLED_ROUTES
+// as well.
+func (s *Server) RegisterRoutes(router *gin.Engine) {
+	s.applyFeatureFlags()
+	disabled := s.cfg.Server.DisabledRoutes
+	if !matchesRoute(disabled, "/") {
+		router.GET("/", cacheControlMiddleware(s.cacheControlPolicy("/", "")), s.IndexHandler)
+		// The dataset pages link back to ../index.html.
+		router.GET("/index.html", cacheControlMiddleware(s.cacheControlPolicy("/index.html", "")), s.IndexHandler)
+	}
+	if !matchesRoute(disabled, "/openapi.json") {
+		router.GET("/openapi.json", cacheControlMiddleware(s.cacheControlPolicy("/openapi.json", "")), s.OpenAPISpecGinHandler)
+	}
+	if !matchesRoute(disabled, "/metrics") && s.cfg.Server.MetricsAddr == "" {
+		router.GET("/metrics", cacheControlMiddleware("no-store"), s.AdminAuthMiddleware(), gin.WrapH(s.MetricsHandler()))
+	}
+	for _, r := range s.Routes {
+		chain := []gin.HandlerFunc{cacheControlMiddleware(s.cacheControlPolicy(r.Path, r.CacheControl))}
+		if r.CacheResponse {
+			chain = append(chain, s.responseCacheMiddleware(r.ExternalData))
+		}
+		router.GET(r.Path, append(chain, r.Handler)...)
+		if r.CacheResponse && r.Prerender {
+			s.prerenderPaths = append(s.prerenderPaths, r.Path)
+			for _, format := range r.Formats {
+				if _, ok := s.renderers[format]; ok {
+					s.prerenderPaths = append(s.prerenderPaths, r.Path+"?format="+format)
+				}
+			}
+		}
+	}
+	// Container health checks such as wget --spider probe with HEAD.
+	if !matchesRoute(disabled, "/healthcheck") {
+		router.HEAD("/healthcheck", cacheControlMiddleware("no-store"), s.HealthcheckGinHandler)
+	}
+	if !matchesRoute(disabled, "/ready") {
+		router.HEAD("/ready", cacheControlMiddleware("no-store"), s.ReadyGinHandler)
+	}
+
+	admin := router.Group("/admin", cacheControlMiddleware("no-store"), s.AdminAuthMiddleware())
+	admin.POST("/cache/flush", s.CacheFlushGinHandler)
+	admin.GET("/cache/stats", s.CacheStatsGinHandler)
+	admin.GET("/sync", s.SyncStatusGinHandler)
+	if s.pprofEnabled() {
+		s.registerPprof(router)
+	}
+
+	router.NoRoute(NotFoundGinHandler)
+	s.setPrerenderRouter(router)
+}
+
+// MetricsHandler serves the Prometheus metrics, at /metrics of the API or on
+// METRICS_ADDR.
+func (s *Server) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(s.metrics, promhttp.HandlerOpts{})
+}
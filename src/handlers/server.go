@@ -0,0 +1,77 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/netip"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/config"
+)
+
+// Server serves the catalog of a catalog.Client over HTTP. Create servers
+// with New; the Gin handlers are its methods.
+type Server struct {
+	cfg     *config.Config
+	catalog *catalog.Client
+	// metrics are served at /metrics or on METRICS_ADDR.
+	metrics prometheus.Gatherer
+
+	// Routes is the registry of all catalog endpoints, without those
+	// switched off by applyFeatureFlags once RegisterRoutes ran.
+	Routes []Route
+	// renderers holds every output format selectable via the "format" query
+	// parameter, without those switched off in DISABLED_FORMATS.
+	renderers map[string]renderer
+
+	// trustedProxies are the addresses whose X-Forwarded-Proto and
+	// X-Forwarded-Host headers are honoured, loaded by LoadTrustedProxies.
+	trustedProxies []netip.Prefix
+
+	// publisherProfiles are the profiles loaded by LoadProfiles, in the order
+	// they are matched against requests.
+	publisherProfiles []*publisherProfile
+	// outputPipeline is the pipeline of OUTPUT_POSTPROCESS, applied to the
+	// outputs of every publisher profile before their own pipeline.
+	outputPipeline pipeline
+
+	// odps31Dump is the export behind /odps31/dump.
+	odps31Dump *catalog.Export
+
+	responseCache map[string]responseItem
+	responseMutex sync.RWMutex
+
+	// prerenderRouter serves the requests of prerenderResponses; it is set by
+	// RegisterRoutes.
+	prerenderRouter atomic.Value // *gin.Engine
+	// prerenderPaths are the request URIs rendered by prerenderResponses.
+	prerenderPaths []string
+
+	clickCounts map[string]int
+	clickMutex  sync.Mutex
+
+	// shuttingDown is set by BeginShutdown; /ready then answers 503.
+	shuttingDown atomic.Bool
+}
+
+// New returns a server of the catalog of client, configured by cfg, serving
+// the metrics of metrics. Its response cache is registered with the caches of
+// client.
+func New(cfg *config.Config, client *catalog.Client, metrics prometheus.Gatherer) *Server {
+	s := &Server{
+		cfg:           cfg,
+		catalog:       client,
+		metrics:       metrics,
+		renderers:     newRenderers(),
+		odps31Dump:    client.NewExport(odpsDumpTTL),
+		responseCache: make(map[string]responseItem),
+		clickCounts:   make(map[string]int),
+	}
+	s.Routes = s.newRoutes()
+	s.registerResponseCache()
+	return s
+}
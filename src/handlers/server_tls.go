@@ -0,0 +1,99 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is how often the certificate files are checked for
+// changes, at most.
+const certCheckInterval = 10 * time.Second
+
+// ServerTLSConfig returns the TLS configuration of the HTTP server if
+// TLS_CERT_FILE and TLS_KEY_FILE, PEM files of a certificate (with its chain)
+// and its key, are set, or nil to serve plain HTTP. The certificate is
+// reloaded when the files change, so renewals need no restart.
+func (s *Server) ServerTLSConfig() (*tls.Config, error) {
+	certFile, keyFile := s.cfg.Server.TLSCertFile, s.cfg.Server.TLSKeyFile
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if certFile == "" {
+		return nil, nil
+	}
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: r.certificate}, nil
+}
+
+// certReloader serves a certificate from files and reloads it when their
+// modification times change.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu              sync.Mutex
+	cert            *tls.Certificate
+	certMod, keyMod time.Time
+	checked         time.Time
+}
+
+// load reads the certificate and key files.
+func (r *certReloader) load() error {
+	certMod, keyMod, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+	return nil
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return cert.ModTime(), key.ModTime(), nil
+}
+
+// certificate returns the current certificate, reloading it first if the
+// files have changed since the last check. A certificate that fails to load,
+// for instance while the files are being replaced, is logged and the previous
+// one kept until the next check.
+func (r *certReloader) certificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < certCheckInterval {
+		return r.cert, nil
+	}
+	r.checked = time.Now()
+	certMod, keyMod, err := r.modTimes()
+	if err == nil && certMod.Equal(r.certMod) && keyMod.Equal(r.keyMod) {
+		return r.cert, nil
+	}
+	if err == nil {
+		err = r.load()
+	}
+	if err != nil {
+		log.Printf("Keeping the current TLS certificate: %v", err)
+		return r.cert, nil
+	}
+	log.Printf("Reloaded TLS certificate from %s", r.certFile)
+	return r.cert, nil
+}
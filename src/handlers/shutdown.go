@@ -0,0 +1,26 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"log"
+	"time"
+)
+
+// ShutdownTimeout returns how long in-flight requests are given to complete
+// on shutdown, SHUTDOWN_TIMEOUT (default 10s).
+func (s *Server) ShutdownTimeout() time.Duration {
+	return s.cfg.Server.ShutdownTimeout
+}
+
+// BeginShutdown makes /ready fail, so load balancers stop routing requests to
+// the instance, and waits SHUTDOWN_DELAY (default 0) for them to notice before
+// the server stops accepting connections.
+func (s *Server) BeginShutdown() {
+	s.shuttingDown.Store(true)
+	if delay := s.cfg.Server.ShutdownDelay; delay > 0 {
+		log.Printf("Waiting %s before closing the listeners", delay)
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,56 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// SitemapGinHandler serves /sitemap.xml listing the browsable page of every
+// dataset in the upstream catalog, using LastChange as lastmod. The YAML and
+// JSON documents are left out, as search engines do not index them.
+func (s *Server) SitemapGinHandler(c *gin.Context) {
+	datasets, err := s.catalog.AllDatasets(c.Request.Context())
+	if err != nil {
+		upstreamUnavailable(c)
+		return
+	}
+
+	urlSet := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, ds := range datasets {
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:     s.publisher(c).BaseURL + "datasets/" + ds.ID + ".html",
+			LastMod: sitemapDate(ds.LastChange),
+		})
+	}
+
+	data, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		problem(c, http.StatusInternalServerError, "Error marshaling XML")
+		return
+	}
+	s.writeData(c, "application/xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
+
+// sitemapDate reduces an upstream timestamp to the YYYY-MM-DD form accepted by lastmod.
+func sitemapDate(ts string) string {
+	if len(ts) < len("2006-01-02") {
+		return ""
+	}
+	return ts[:len("2006-01-02")]
+}
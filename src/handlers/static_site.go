@@ -0,0 +1,121 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// GenerateSite writes the complete catalog as static files into dir, for
+// hosting on object storage or GitHub Pages without running the service:
+//   - catalog.jsonld and catalog.ttl, the DCAT catalog;
+//   - odps31/{uuid}.yaml, the ODPS 3.1 document of every dataset;
+//   - index.html and datasets/{uuid}.html, browsable pages linking them;
+//   - sitemap.xml, listing the dataset pages.
+//
+// Links in the documents and the sitemap are built from baseURL, the URL the
+// files will be served from, and the HTML pages link each other relatively.
+// deprecated is the ?deprecated= policy. It returns the number of datasets
+// written.
+func (s *Server) GenerateSite(ctx context.Context, dir, baseURL, deprecated string) (int, error) {
+	pages, err := s.LoadTemplates()
+	if err != nil {
+		return 0, err
+	}
+	all, err := s.catalog.AllDatasets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	datasets := catalog.FilterDeprecated(deprecated, all)
+	p := s.cfg.Publisher
+	p.BaseURL = baseURL
+	lang := transformers.DefaultLanguage
+
+	dcat := s.catalog.DCATCatalog(p, datasets, lang)
+	jsonld, err := json.Marshal(dcat)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSiteFile(dir, "catalog.jsonld", jsonld); err != nil {
+		return 0, err
+	}
+	ttl, err := transformers.ToTurtle(dcat)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSiteFile(dir, "catalog.ttl", []byte(ttl)); err != nil {
+		return 0, err
+	}
+
+	docs := s.catalog.ODPS31Documents(p, datasets, lang)
+	urlSet := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for i, ds := range datasets {
+		// IDs become file names, so only the usual upstream IDs are written.
+		if !datasetIDPattern.MatchString(ds.ID) {
+			log.Printf("Skipping dataset with unusual ID %q", ds.ID)
+			continue
+		}
+		doc, err := yaml.Marshal(docs[i])
+		if err != nil {
+			return 0, err
+		}
+		if err := writeSiteFile(dir, filepath.Join("odps31", ds.ID+".yaml"), doc); err != nil {
+			return 0, err
+		}
+		var page bytes.Buffer
+		err = pages.ExecuteTemplate(&page, "site_dataset.html", map[string]interface{}{
+			"publisher":   p,
+			"dataset":     ds,
+			"description": ds.ApiDescription[lang],
+		})
+		if err != nil {
+			return 0, err
+		}
+		if err := writeSiteFile(dir, filepath.Join("datasets", ds.ID+".html"), page.Bytes()); err != nil {
+			return 0, err
+		}
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{Loc: baseURL + "datasets/" + ds.ID + ".html", LastMod: sitemapDate(ds.LastChange)})
+	}
+
+	var index bytes.Buffer
+	err = pages.ExecuteTemplate(&index, "site_index.html", map[string]interface{}{
+		"publisher": p,
+		"datasets":  datasets,
+		"generated": time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSiteFile(dir, "index.html", index.Bytes()); err != nil {
+		return 0, err
+	}
+	sitemap, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSiteFile(dir, "sitemap.xml", append([]byte(xml.Header), sitemap...)); err != nil {
+		return 0, err
+	}
+	return len(datasets), nil
+}
+
+// writeSiteFile writes data to name below dir, creating its directory.
+func writeSiteFile(dir, name string, data []byte) error {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
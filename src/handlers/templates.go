@@ -0,0 +1,41 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"log"
+	"os"
+)
+
+// bundledTemplates are the HTML templates of the index page and the static
+// site, compiled into the binary so it runs outside the repository layout.
+//
+//go:embed templates/*.html
+var bundledTemplates embed.FS
+
+// LoadTemplates parses the HTML templates: the bundled ones, each replaced by
+// the file of the same name in TEMPLATES_DIR if set, so deployments can brand
+// the pages without rebuilding.
+func (s *Server) LoadTemplates() (*template.Template, error) {
+	tmpl, err := template.ParseFS(bundledTemplates, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+	dir := s.cfg.Server.TemplatesDir
+	if dir == "" {
+		return tmpl, nil
+	}
+	overrides, err := fs.Glob(os.DirFS(dir), "*.html")
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		log.Printf("No templates found in %s, using the bundled ones", dir)
+		return tmpl, nil
+	}
+	return tmpl.ParseFS(os.DirFS(dir), overrides...)
+}
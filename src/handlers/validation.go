@@ -0,0 +1,90 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/catalog"
+)
+
+// datasetIDPattern matches the dataset identifiers used by the upstream MetaData API.
+var datasetIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+// ValidationMiddleware rejects requests with malformed common parameters
+// (page, pageSize, format, lang, deprecated, the upstream filters and the
+// :uuid path parameter) with
+// a 400 problem response, before they reach a handler or the upstream API.
+// Catalogs of publisher profiles limited to some dataspaces reject the
+// upstream filters, which would apply to the whole upstream catalog.
+func (s *Server) ValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := s.validateParams(c); err != nil {
+			problem(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) validateParams(c *gin.Context) error {
+	query := c.Request.URL.Query()
+	if query.Has("page") {
+		if p, err := strconv.Atoi(query.Get("page")); err != nil || p < 1 {
+			return fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if query.Has("pageSize") {
+		if s, err := strconv.Atoi(query.Get("pageSize")); err != nil || s < 1 || s > catalog.MaxPageSize {
+			return fmt.Errorf("pageSize must be an integer between 1 and %d", catalog.MaxPageSize)
+		}
+	}
+	if query.Has("format") && !s.formatSupported(c.FullPath(), query.Get("format")) {
+		return fmt.Errorf("unsupported format %q", query.Get("format"))
+	}
+	if query.Has("lang") && !supportedLanguages[strings.ToLower(query.Get("lang"))] {
+		return fmt.Errorf("unsupported lang %q", query.Get("lang"))
+	}
+	if query.Has("deprecated") {
+		switch query.Get("deprecated") {
+		case "include", "exclude", "only":
+		default:
+			return fmt.Errorf("deprecated must be one of include, exclude, only")
+		}
+	}
+	restricted := s.publisherProfileFrom(c.Request.Context()).Dataspaces.Restricted()
+	for _, name := range upstreamFilterParams {
+		if restricted && query.Has(name) {
+			return fmt.Errorf("%s is not supported by this catalog", name)
+		}
+		v := query.Get(name)
+		if len(v) > maxFilterLength || strings.ContainsFunc(v, unicode.IsControl) {
+			return fmt.Errorf("%s must be at most %d printable characters", name, maxFilterLength)
+		}
+	}
+	if id, ok := c.Params.Get("uuid"); ok && !datasetIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid dataset ID %q", id)
+	}
+	return nil
+}
+
+// formatSupported reports whether format can be requested from the route at
+// path: one of the Formats of a route of the registry, or any renderer for the
+// routes outside it, such as /openapi.json.
+func (s *Server) formatSupported(path, format string) bool {
+	for _, r := range s.Routes {
+		if r.Path == path {
+			return slices.Contains(r.Formats, format)
+		}
+	}
+	_, ok := s.renderers[format]
+	return ok
+}
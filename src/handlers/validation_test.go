@@ -0,0 +1,48 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidationMiddleware(t *testing.T) {
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"/dcat", http.StatusOK},
+		{"/dcat?page=1&pageSize=100", http.StatusOK},
+		{"/dcat?page=0", http.StatusBadRequest},
+		{"/dcat?page=-1", http.StatusBadRequest},
+		{"/dcat?page=first", http.StatusBadRequest},
+		{"/dcat?page=", http.StatusBadRequest},
+		{"/dcat?pageSize=0", http.StatusBadRequest},
+		{"/dcat?pageSize=101", http.StatusBadRequest},
+		{"/dcat?format=ttl", http.StatusOK},
+		{"/dcat?format=xml", http.StatusBadRequest},
+		{"/odps?format=ttl", http.StatusBadRequest},
+		{"/odps31/dump?format=toml", http.StatusBadRequest},
+		{"/dcat?lang=de", http.StatusOK},
+		{"/dcat?lang=DE", http.StatusOK},
+		{"/dcat?lang=fr", http.StatusBadRequest},
+		{"/dcat?deprecated=only", http.StatusOK},
+		{"/dcat?deprecated=all", http.StatusBadRequest},
+		{"/dcat?deprecated=", http.StatusBadRequest},
+		{"/dcat?searchfilter=%01", http.StatusBadRequest},
+		{"/odps31/ds-01", http.StatusOK},
+		{"/odps31/ds%2001", http.StatusBadRequest},
+		{"/odps31/-ds", http.StatusBadRequest},
+	}
+	router := newTestRouter(t, 5)
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(router, tt.target, nil)
+			if w.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
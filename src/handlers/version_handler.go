@@ -0,0 +1,45 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Build metadata, injected at build time, e.g.:
+//
+//	go build -ldflags "-X opendatahub.com/dataset-catalog-api/handlers.Version=1.2.0 \
+//	  -X opendatahub.com/dataset-catalog-api/handlers.GitCommit=$(git rev-parse HEAD) \
+//	  -X opendatahub.com/dataset-catalog-api/handlers.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// VersionGinHandler serves /version with the build metadata and the supported
+// output formats and profiles.
+func (s *Server) VersionGinHandler(c *gin.Context) {
+	formats := make([]string, 0, len(s.renderers))
+	for format := range s.renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	profiles := make([]string, 0, len(datasetProfiles))
+	for name := range datasetProfiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+
+	c.JSON(http.StatusOK, gin.H{
+		"version":   Version,
+		"gitCommit": GitCommit,
+		"buildDate": BuildDate,
+		"formats":   formats,
+		"profiles":  profiles,
+	})
+}
@@ -0,0 +1,20 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"opendatahub.com/dataset-catalog-api/transformers"
+)
+
+// VoIDGinHandler serves /.well-known/void, a VoID description of the whole catalog.
+func (s *Server) VoIDGinHandler(c *gin.Context) {
+	datasets, err := s.catalog.AllDatasets(c.Request.Context())
+	if err != nil {
+		upstreamUnavailable(c)
+		return
+	}
+	output := transformers.ToVoID(s.publisher(c), datasets)
+	s.render(c, output, "json")
+}
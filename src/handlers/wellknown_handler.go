@@ -0,0 +1,39 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RobotsGinHandler serves /robots.txt. Allowed and disallowed path prefixes are
+// configured via ROBOTS_ALLOW and ROBOTS_DISALLOW (comma-separated).
+func (s *Server) RobotsGinHandler(c *gin.Context) {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range s.cfg.Server.RobotsAllow {
+		b.WriteString("Allow: " + p + "\n")
+	}
+	for _, p := range s.cfg.Server.RobotsDisallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	b.WriteString("\nSitemap: " + s.publisher(c).BaseURL + "sitemap.xml\n")
+	c.String(http.StatusOK, b.String())
+}
+
+// WellKnownGinHandler serves /.well-known/, pointing automated clients at the
+// machine-readable descriptions of the catalog.
+func (s *Server) WellKnownGinHandler(c *gin.Context) {
+	baseURL := s.publisher(c).BaseURL
+	c.JSON(http.StatusOK, gin.H{
+		"dcat":    baseURL + "dcat/dump",
+		"catalog": baseURL + "catalog.jsonld",
+		"sitemap": baseURL + "sitemap.xml",
+		"openapi": baseURL + "openapi.json",
+		"void":    baseURL + ".well-known/void",
+	})
+}
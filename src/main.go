@@ -4,47 +4,315 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/grpc"
+	"opendatahub.com/dataset-catalog-api/catalog"
+	"opendatahub.com/dataset-catalog-api/catalogpb"
+	"opendatahub.com/dataset-catalog-api/config"
 	"opendatahub.com/dataset-catalog-api/handlers"
+	"opendatahub.com/dataset-catalog-api/transformers"
 )
 
-func init() {
-	// Load environment variables from .env if available.
+func main() {
+	// Load environment variables from .env if available, then build the
+	// configuration of every package from the environment.
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
+	a := newApp(config.FromEnv())
+
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	switch command {
+	case "serve":
+		serve(a, args)
+	case "dump":
+		os.Exit(dumpCommand(a, args))
+	case "generate":
+		os.Exit(generateCommand(a, args))
+	case "validate":
+		os.Exit(validateCommand(a, args))
+	case "sync":
+		os.Exit(syncCommand(a, args))
+	case "bench":
+		os.Exit(benchCommand(a, args))
+	case "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		usage()
+		os.Exit(2)
+	}
 }
 
-func main() {
-  mode := os.Getenv("GIN_MODE")
-	if mode == "" {
-		// Se non impostato, usa la modalità "release"
-		gin.SetMode(gin.ReleaseMode)
-	} else {
-		// Altrimenti, usa il valore specificato nell'ambiente
-		gin.SetMode(mode)
+// app holds what the commands run on: the configuration, the client of the
+// upstream catalog and the server of the API.
+type app struct {
+	cfg     *config.Config
+	catalog *catalog.Client
+	server  *handlers.Server
+}
+
+// newApp builds the catalog client and the server of cfg. Their metrics are
+// gathered in a registry of their own, with those of the Go runtime and the
+// process.
+func newApp(cfg *config.Config) *app {
+	// Identify the requests to the MetaData API with the build version,
+	// unless UPSTREAM_USER_AGENT is set.
+	if cfg.Upstream.UserAgent == "" {
+		cfg.Upstream.UserAgent = "dataset-catalog-api/" + handlers.Version
+	}
+	client := catalog.New(cfg)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	registry.MustRegister(client.Collectors()...)
+	return &app{cfg: cfg, catalog: client, server: handlers.New(cfg, client, registry)}
+}
+
+// setupCatalog configures the cache and the upstream access shared by the
+// server and the other commands.
+func setupCatalog(a *app) {
+	if err := a.catalog.UseCacheBackend(a.cfg.Cache.Backend); err != nil {
+		log.Fatalf("Failed to set up cache backend: %v", err)
+	}
+	if budget := a.cfg.Cache.MemoryBudget; budget > 0 {
+		log.Printf("Limiting the page, detail and response caches to %d bytes", budget)
+	}
+
+	if err := a.catalog.CheckUpstreamTLS(); err != nil {
+		log.Fatalf("Invalid upstream TLS configuration: %v", err)
+	}
+
+	// Fill the ODPS sections without upstream data from ODPS_DEFAULTS_FILE.
+	if defaultsFile := a.cfg.ODPSDefaultsFile; defaultsFile != "" {
+		defaults, err := transformers.LoadODPSDefaults(defaultsFile)
+		if err != nil {
+			log.Fatalf("Failed to load ODPS defaults %s: %v", defaultsFile, err)
+		}
+		a.cfg.Publisher.ODPSDefaults = defaults
+	}
+
+	// Map the upstream license values to canonical licenses from LICENSES_FILE.
+	if licensesFile := a.cfg.LicensesFile; licensesFile != "" {
+		licenses, err := transformers.LoadLicenses(licensesFile)
+		if err != nil {
+			log.Fatalf("Failed to load licenses %s: %v", licensesFile, err)
+		}
+		a.cfg.Publisher.Licenses = licenses
+	}
+
+	// Patch the upstream metadata of single datasets from DATASET_OVERRIDES_FILE.
+	if overridesFile := a.cfg.DatasetOverridesFile; overridesFile != "" {
+		if err := a.catalog.LoadDatasetOverrides(overridesFile); err != nil {
+			log.Fatalf("Failed to load dataset overrides %s: %v", overridesFile, err)
+		}
+	}
+
+	// Rewrite the JSON, YAML and TOML outputs with the steps of OUTPUT_POSTPROCESS.
+	if err := a.server.LoadPostProcessing(); err != nil {
+		log.Fatalf("Invalid OUTPUT_POSTPROCESS: %v", err)
+	}
+
+	// Keep a copy of the upstream catalog on disk if CACHE_FILE is set.
+	if cacheFile := a.cfg.Cache.File; cacheFile != "" {
+		if err := a.catalog.OpenPersistentCache(cacheFile); err != nil {
+			log.Fatalf("Failed to open persistent cache %s: %v", cacheFile, err)
+		}
+	}
+
+	// Restore the caches saved on the last shutdown if CACHE_STATE_FILE is set.
+	if stateFile := a.cfg.Cache.StateFile; stateFile != "" {
+		if err := a.catalog.RestoreCacheState(stateFile); err != nil {
+			log.Printf("Not restoring cache state from %s: %v", stateFile, err)
+		}
+	}
+
+	// In OFFLINE_MODE serve the fixtures at OFFLINE_FIXTURES, or the bundled
+	// ones, without any upstream request. Otherwise seed the cache from an
+	// exported catalog if CACHE_SEED is set.
+	if a.catalog.OfflineMode() {
+		if err := a.catalog.LoadFixtures(a.cfg.OfflineFixtures); err != nil {
+			log.Fatalf("Failed to load offline fixtures: %v", err)
+		}
+	} else if seed := a.cfg.Cache.Seed; seed != "" {
+		if err := a.catalog.LoadSnapshot(seed); err != nil {
+			log.Fatalf("Failed to load snapshot %s: %v", seed, err)
+		}
+	}
+}
+
+// newHandler returns the HTTP handler of the API, with middleware in front of
+// the handler's own.
+func newHandler(a *app, middleware ...gin.HandlerFunc) http.Handler {
+	// Build links from the forwarded scheme and host of requests from the
+	// proxies in TRUSTED_PROXIES.
+	if err := a.server.LoadTrustedProxies(); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Serve several branded catalogs, selected by hostname or path prefix,
+	// if PUBLISHER_PROFILES_FILE is set.
+	if profilesFile := a.cfg.PublisherProfilesFile; profilesFile != "" {
+		if err := a.server.LoadProfiles(profilesFile); err != nil {
+			log.Fatalf("Failed to load publisher profiles %s: %v", profilesFile, err)
+		}
 	}
-	router := gin.Default()
 
-	// Load HTML templates from the "templates" directory.
-	router.LoadHTMLGlob("templates/*.html")
+	router := gin.New()
+	router.Use(middleware...)
+	router.Use(gin.Recovery())
+	router.Use(handlers.RequestIDMiddleware())
+	router.Use(a.server.ValidationMiddleware())
 
-	// Register the index route using the dedicated handler.
-	router.GET("/", handlers.IndexHandler)
+	// Use the bundled HTML templates, or those in TEMPLATES_DIR.
+	tmpl, err := a.server.LoadTemplates()
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+	router.SetHTMLTemplate(tmpl)
+
+	// Register the index page and every endpoint of the route registry.
+	a.server.RegisterRoutes(router)
 
-	// Register other endpoints.
-	router.GET("/dcat", handlers.DcatGinHandler)
-	router.GET("/odps", handlers.ODPSGinHandler)
-	router.GET("/odps30", handlers.ODPS30GinHandler)
-	router.GET("/odps30/:uuid", handlers.ODPS30DetailGinHandler)
-	router.GET("/odps31", handlers.ODPS31GinHandler)
-	router.GET("/odps31/:uuid", handlers.ODPS31DetailGinHandler)
+	// Accept format extensions (/dcat.ttl, /odps31/{uuid}.yaml) as an
+	// alternative to the format query parameter, and select the publisher
+	// profile of each request.
+	return a.server.ProfileHandler(a.server.FormatSuffixHandler(router))
+}
 
-	fmt.Println("Server running on :8878")
-	log.Fatal(router.Run(":8878"))
+// serve runs the HTTP and gRPC servers until SIGINT or SIGTERM.
+func serve(a *app, args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: dataset-catalog-api serve\n\nRuns the HTTP API and the gRPC CatalogService, configured by the environment.")
+	}
+	flags.Parse(args)
+
+	gin.SetMode(a.cfg.Server.GinMode)
+	setupCatalog(a)
+
+	// Serve HTTPS if TLS_CERT_FILE and TLS_KEY_FILE are set.
+	tlsConfig, err := a.server.ServerTLSConfig()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	// Share cache invalidations with other instances via Redis pub/sub if
+	// INVALIDATION_REDIS_URL is set.
+	if redisURL := a.cfg.Cache.InvalidationRedis; redisURL != "" {
+		if err := a.catalog.StartInvalidation(redisURL, a.cfg.Cache.InvalidationChannel); err != nil {
+			log.Fatalf("Failed to subscribe to cache invalidations: %v", err)
+		}
+	}
+
+	// Re-sync the whole catalog on the SYNC_SCHEDULE cron schedule, if set.
+	if schedule := a.cfg.SyncSchedule; schedule != "" {
+		if err := a.catalog.StartSyncScheduler(schedule); err != nil {
+			log.Fatalf("Invalid SYNC_SCHEDULE %q: %v", schedule, err)
+		}
+	}
+
+	a.catalog.StartCacheJanitor()
+
+	handler := newHandler(a, gin.Logger())
+
+	// Serve the gRPC CatalogService alongside the HTTP API.
+	grpcPort := a.cfg.Server.GRPCPort
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on :%s: %v", grpcPort, err)
+	}
+	grpcServer := grpc.NewServer()
+	catalogpb.RegisterCatalogServiceServer(grpcServer, handlers.CatalogServer{Catalog: a.catalog})
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	fmt.Println("gRPC server running on :" + grpcPort)
+
+	// Serve /metrics on METRICS_ADDR, e.g. a port only Prometheus can reach,
+	// instead of behind the admin token.
+	var metricsServer *http.Server
+	if addr := a.cfg.Server.MetricsAddr; addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", a.server.MetricsHandler())
+		metricsServer = &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+		fmt.Println("Metrics served on " + addr)
+	}
+
+	if tlsConfig != nil {
+		fmt.Println("Server running on :8878 (HTTPS)")
+	} else {
+		fmt.Println("Server running on :8878")
+	}
+	srv := &http.Server{Addr: ":8878", Handler: handler, TLSConfig: tlsConfig}
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Shut down gracefully on SIGINT/SIGTERM and save the caches for the next start.
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down")
+	a.server.BeginShutdown()
+	// Stop accepting connections and give in-flight requests and streams
+	// SHUTDOWN_TIMEOUT to complete before they are cut off.
+	ctx, cancel := context.WithTimeout(context.Background(), a.server.ShutdownTimeout())
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+		srv.Close()
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		log.Println("Cancelling remaining gRPC streams")
+		grpcServer.Stop()
+	}
+	a.catalog.StopBackground(ctx)
+	if stateFile := a.cfg.Cache.StateFile; stateFile != "" {
+		if err := a.catalog.SaveCacheState(stateFile); err != nil {
+			log.Printf("Error saving cache state to %s: %v", stateFile, err)
+		}
+	}
+	log.Println("Shutdown complete")
 }
@@ -3,72 +3,147 @@
 
 package transformers
 
-import (
-	"log"
-	"os"
+import "sort"
 
-	"github.com/joho/godotenv"
-)
+// Publisher is the organisation a catalog is published by, and the base URL
+// the catalog is served under. Title and Description are the catalog's, by
+// language; see CatalogTitle and CatalogDescription. Licenses and
+// ODPSDefaults configure the outputs of the service as a whole, so publisher
+// profiles do not set them but share those of the default publisher.
+type Publisher struct {
+	BaseURL        string `yaml:"baseURL"`
+	Name           string `yaml:"name"`
+	URL            string `yaml:"url"`
+	Slogan         string `yaml:"slogan"`
+	ContactName    string `yaml:"contactName"`
+	ContactEmail   string `yaml:"contactEmail"`
+	ContactPhone   string `yaml:"contactPhone"`
+	ContactWebsite string `yaml:"contactWebsite"`
+	StreetAddress  string `yaml:"streetAddress"`
+	PostalCode     string `yaml:"postalCode"`
+	Locality       string `yaml:"locality"`
+	Region         string `yaml:"region"`
+	Country        string `yaml:"country"`
+	VatID          string `yaml:"vatID"`
+	TaxID          string `yaml:"taxID"`
 
-var BaseURL string
+	Title       map[string]string `yaml:"title"`
+	Description map[string]string `yaml:"description"`
 
-func init() {
-	// Load environment variables from .env if available.
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+	Licenses     Licenses     `yaml:"-"`
+	ODPSDefaults ODPSDefaults `yaml:"-"`
+}
+
+// CatalogTitle returns the title of the catalog by language, by default
+// "{Name} API Catalog" in English.
+func (p Publisher) CatalogTitle() map[string]string {
+	if len(p.Title) > 0 {
+		return p.Title
 	}
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://data-catalog.opendatahub.testingmachine.eu/"
+	return map[string]string{DefaultLanguage: p.Name + " API Catalog"}
+}
+
+// CatalogDescription returns the description of the catalog by language, by
+// default "A catalog of APIs provided by {Name}." in English.
+func (p Publisher) CatalogDescription() map[string]string {
+	if len(p.Description) > 0 {
+		return p.Description
 	}
-	BaseURL = baseURL
+	return map[string]string{DefaultLanguage: "A catalog of APIs provided by " + p.Name + "."}
 }
 
-var (
-	ContactEmail       = "help@opendatahub.com"
-	ContactPhoneNumber = "+390471066600"
-	ContactWebsite     = "https://opendatahub.com"
-	OrganizationName   = "Noi Spa"
-	OrganizationURL    = "https://noi.bz.it"
-	BrandSlogan        = "Develop digital solutions based on real data"
-	PostalCode         = "39100"
-	StreetAddress      = "Via Volta 13/A"
-	AddressLocality    = "Bolzano"
-	AddressRegion      = "Alto Adige"
-	VatID              = "IT02595720216"
-	TaxID              = "IT02595720216"
-)
+// localized returns the text of texts in lang, falling back to
+// DefaultLanguage and then to the first language in alphabetical order.
+func localized(texts map[string]string, lang string) string {
+	if t, ok := texts[lang]; ok {
+		return t
+	}
+	if t, ok := texts[DefaultLanguage]; ok {
+		return t
+	}
+	langs := make([]string, 0, len(texts))
+	for l := range texts {
+		langs = append(langs, l)
+	}
+	sort.Strings(langs)
+	if len(langs) == 0 {
+		return ""
+	}
+	return texts[langs[0]]
+}
+
+// DefaultPublisher describes the Open Data Hub, the publisher of the catalog
+// unless configured otherwise. It is the starting point of the configured
+// publisher (config.Default), which is passed to the transformers; nothing
+// changes DefaultPublisher itself.
+var DefaultPublisher = Publisher{
+	BaseURL:        "https://data-catalog.opendatahub.testingmachine.eu/",
+	Name:           "Noi Spa",
+	URL:            "https://noi.bz.it",
+	Slogan:         "Develop digital solutions based on real data",
+	ContactName:    "Support Open Data Hub",
+	ContactEmail:   "help@opendatahub.com",
+	ContactPhone:   "+390471066600",
+	ContactWebsite: "https://opendatahub.com",
+	StreetAddress:  "Via Volta 13/A",
+	PostalCode:     "39100",
+	Locality:       "Bolzano",
+	Region:         "Alto Adige",
+	Country:        "IT",
+	VatID:          "IT02595720216",
+	TaxID:          "IT02595720216",
+}
 
 // Dataset represents the internal dataset structure.
 type Dataset struct {
-	ID             string              `json:"Id"`
-	Self           string              `json:"Self"`
-	Type           string              `json:"Type"`
-	Meta           MetaData            `json:"_Meta"`
-	ApiUrl         string              `json:"ApiUrl"`
-	Output         interface{}         `json:"Output"`
-	ApiType        string              `json:"ApiType"`
-	BaseUrl        string              `json:"BaseUrl"`
-	ODHTags        []interface{}       `json:"ODHTags"`
-	OdhType        interface{}         `json:"OdhType"`
-	Sources        interface{}         `json:"Sources"`
-	Category       []string            `json:"Category"`
-	ApiAccess      interface{}         `json:"ApiAccess"`
-	ApiFilter      []string            `json:"ApiFilter"`
-	Dataspace      string              `json:"Dataspace"`
-	OdhTagIds      interface{}         `json:"OdhTagIds"`
-	PathParam      []string            `json:"PathParam"`
-	Shortname      string              `json:"Shortname"`
-	Deprecated     bool                `json:"Deprecated"`
-	LastChange     string              `json:"LastChange"`
-	SwaggerUrl     string              `json:"SwaggerUrl"`
-	FirstImport    string              `json:"FirstImport"`
-	LicenseInfo    LicenseInfo         `json:"LicenseInfo"`
-	PublishedOn    []interface{}       `json:"PublishedOn"`
-	RecordCount    interface{}         `json:"RecordCount"`
-	DataProvider   []string            `json:"DataProvider"`
-	ImageGallery   []ImageGalleryItem  `json:"ImageGallery"`
-	ApiDescription map[string]string   `json:"ApiDescription"`
+	ID             string             `json:"Id"`
+	Self           string             `json:"Self"`
+	Type           string             `json:"Type"`
+	Meta           MetaData           `json:"_Meta"`
+	ApiUrl         string             `json:"ApiUrl"`
+	Output         interface{}        `json:"Output"`
+	ApiType        string             `json:"ApiType"`
+	BaseUrl        string             `json:"BaseUrl"`
+	ODHTags        []interface{}      `json:"ODHTags"`
+	OdhType        interface{}        `json:"OdhType"`
+	Sources        interface{}        `json:"Sources"`
+	Category       []string           `json:"Category"`
+	ApiAccess      interface{}        `json:"ApiAccess"`
+	ApiFilter      []string           `json:"ApiFilter"`
+	Dataspace      string             `json:"Dataspace"`
+	OdhTagIds      interface{}        `json:"OdhTagIds"`
+	PathParam      []string           `json:"PathParam"`
+	Shortname      string             `json:"Shortname"`
+	Deprecated     bool               `json:"Deprecated"`
+	LastChange     string             `json:"LastChange"`
+	SwaggerUrl     string             `json:"SwaggerUrl"`
+	FirstImport    string             `json:"FirstImport"`
+	LicenseInfo    LicenseInfo        `json:"LicenseInfo"`
+	PublishedOn    []interface{}      `json:"PublishedOn"`
+	RecordCount    interface{}        `json:"RecordCount"`
+	DataProvider   []string           `json:"DataProvider"`
+	ImageGallery   []ImageGalleryItem `json:"ImageGallery"`
+	ApiDescription map[string]string  `json:"ApiDescription"`
+}
+
+// DefaultLanguage is used for datasets without a description in the requested language.
+const DefaultLanguage = "en"
+
+// resolveLanguage returns lang if the dataset has a description in that
+// language, and DefaultLanguage otherwise.
+func resolveLanguage(ds Dataset, lang string) string {
+	if ds.ApiDescription[lang] != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// productStatus returns the ODPS product status of a dataset.
+func productStatus(ds Dataset) string {
+	if ds.Deprecated {
+		return "deprecated"
+	}
+	return "active"
 }
 
 // MetaData represents metadata information.
@@ -111,4 +186,3 @@ type ImageGalleryItem struct {
 	ListPosition  interface{}            `json:"ListPosition"`
 	LicenseHolder interface{}            `json:"LicenseHolder"`
 }
-
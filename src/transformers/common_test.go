@@ -0,0 +1,37 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// benchDatasetCount is the number of datasets transformed per benchmark
+// iteration, the size of a full upstream page.
+const benchDatasetCount = 100
+
+// benchDatasets returns benchDatasetCount datasets built from the offline
+// fixtures of the catalog, with distinct IDs.
+func benchDatasets(b *testing.B) []Dataset {
+	b.Helper()
+	body, err := os.ReadFile("../catalog/fixtures/metadata.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var page struct {
+		Items []Dataset `json:"Items"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		b.Fatal(err)
+	}
+	datasets := make([]Dataset, benchDatasetCount)
+	for i := range datasets {
+		datasets[i] = page.Items[i%len(page.Items)]
+		datasets[i].ID = fmt.Sprintf("%s-%d", datasets[i].ID, i)
+	}
+	return datasets
+}
@@ -5,97 +5,226 @@ package transformers
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
 // ToDCAT maps a slice of datasets to a DCAT‑AP 3.0 compliant catalog.
 // It uses qualified properties (e.g., dct:title, dct:description, dct:type),
 // language‑tagged values, and adds mandatory metadata (such as dct:identifier, dct:issued, and dct:modified).
-func ToDCAT(datasets []Dataset) map[string]interface{} {
-	now := time.Now().Format("2006-01-02")
-	var datasetList []map[string]interface{}
-	for _, ds := range datasets {
-		datasetList = append(datasetList, map[string]interface{}{
-			"@type":          "dcat:Dataset",
-			"@id":            ds.Self,
-			"dct:identifier": ds.ID,
-			// Mandatory property: dct:type
-			"dct:type": map[string]string{
-				"en": "dcat:Dataset",
-			},
-			"dct:title": map[string]string{
-				"en": ds.Shortname,
-			},
-			"dct:description": map[string]string{
-				"en": fmt.Sprintf("Dataset type: %s", ds.Type),
-			},
-			"dct:issued":   ds.FirstImport,
-			"dct:modified": ds.LastChange,
-			"distribution": []map[string]interface{}{
-				{
-					"@type":          "dcat:Distribution",
-					"@id":            ds.ApiUrl,
-					"dct:identifier": ds.ApiUrl, // using API URL as identifier
-					"dct:type": map[string]string{
-						"en": "dcat:Distribution",
-					},
-					"dct:title": map[string]string{
-						"en": ds.Shortname + " API Endpoint",
-					},
-					"dct:format": "application/json",
-					"accessURL":  ds.ApiUrl,
-				},
-			},
-		})
-	}
+// Dataset titles and descriptions are tagged with lang where a translation exists.
+func ToDCAT(p Publisher, datasets []Dataset, lang string) *Catalog {
+	catalog := DCATCatalog(p)
+	catalog.Datasets = MapDatasets(datasets, func(ds Dataset) DCATDataset {
+		return ToDCATDataset(p, ds, lang)
+	})
+	return catalog
+}
 
-	return map[string]interface{}{
-		"@context": map[string]interface{}{
-			"dcat": "https://www.w3.org/ns/dcat#",
-			"dct":  "http://purl.org/dc/terms/",
-			"foaf": "http://xmlns.com/foaf/0.1/",
-			"xsd":  "http://www.w3.org/2001/XMLSchema#",
-			// Define language containers:
-			"dct:title": map[string]interface{}{
-				"@id":       "dct:title",
-				"@container": "@language",
-			},
-			"dct:description": map[string]interface{}{
-				"@id":       "dct:description",
-				"@container": "@language",
-			},
-			"dct:issued": map[string]interface{}{
-				"@id":   "dct:issued",
-				"@type": "xsd:date",
-			},
-			"dct:modified": map[string]interface{}{
-				"@id":   "dct:modified",
-				"@type": "xsd:date",
-			},
-		},
-		"@type": "dcat:Catalog",
-		"@id":   BaseURL + "api-catalog",
+// dcatContext is the JSON-LD @context of every catalog.
+var dcatContext = map[string]interface{}{
+	"dcat": "https://www.w3.org/ns/dcat#",
+	"dct":  "http://purl.org/dc/terms/",
+	"foaf": "http://xmlns.com/foaf/0.1/",
+	"xsd":  "http://www.w3.org/2001/XMLSchema#",
+	"owl":  "http://www.w3.org/2002/07/owl#",
+	// Define language containers:
+	"dct:title": map[string]interface{}{
+		"@id":        "dct:title",
+		"@container": "@language",
+	},
+	"dct:description": map[string]interface{}{
+		"@id":        "dct:description",
+		"@container": "@language",
+	},
+	"dct:issued": map[string]interface{}{
+		"@id":   "dct:issued",
+		"@type": "xsd:date",
+	},
+	"dct:modified": map[string]interface{}{
+		"@id":   "dct:modified",
+		"@type": "xsd:date",
+	},
+}
+
+// DCATCatalog returns the catalog-level DCAT document without any datasets.
+func DCATCatalog(p Publisher) *Catalog {
+	now := time.Now().Format("2006-01-02")
+	return &Catalog{
+		Context: dcatContext,
+		Type:    "dcat:Catalog",
+		ID:      p.BaseURL + "api-catalog",
 		// Mandatory property for catalog:
-		"dct:type": map[string]string{
-			"en": "dcat:Catalog",
-		},
-		"dct:identifier": "catalog-001",
-		"dct:title": map[string]string{
-			"en": OrganizationName + " API Catalog",
-		},
-		"dct:description": map[string]string{
-			"en": "A catalog of APIs provided by " + OrganizationName + ".",
-		},
-		"dct:issued":   now,
-		"dct:modified": now,
-		"publisher": map[string]interface{}{
-			"@type":          "foaf:Organization",
-			"dct:identifier": "org-001",
-			"dct:title": map[string]string{
-				"en": OrganizationName,
-			},
-			"homepage": OrganizationURL,
+		DCTType:     LangMap{"en": "dcat:Catalog"},
+		Identifier:  "catalog-001",
+		Title:       p.CatalogTitle(),
+		Description: p.CatalogDescription(),
+		Issued:      now,
+		Modified:    now,
+		Publisher: Organization{
+			Type:       "foaf:Organization",
+			Identifier: "org-001",
+			Title:      LangMap{"en": p.Name},
+			Homepage:   p.URL,
 		},
-		"dataset": datasetList,
 	}
 }
+
+// ToDCATDataset maps a single dataset to a dcat:Dataset node, using its
+// description in lang (falling back to DefaultLanguage) and the licenses of p.
+func ToDCATDataset(p Publisher, ds Dataset, lang string) DCATDataset {
+	lang = resolveLanguage(ds, lang)
+	description := ds.ApiDescription[lang]
+	if description == "" {
+		description = fmt.Sprintf("Dataset type: %s", ds.Type)
+	}
+	return DCATDataset{
+		Type:       "dcat:Dataset",
+		ID:         ds.Self,
+		Identifier: ds.ID,
+		// Mandatory property: dct:type
+		DCTType:       LangMap{"en": "dcat:Dataset"},
+		Title:         LangMap{lang: ds.Shortname},
+		Description:   LangMap{lang: description},
+		Language:      lang,
+		Issued:        ds.FirstImport,
+		Modified:      ds.LastChange,
+		Deprecated:    ds.Deprecated,
+		Distributions: dcatDistributions(p.Licenses, ds),
+	}
+}
+
+// mediaTypes maps upstream Output format names to IANA media types.
+var mediaTypes = map[string]string{
+	"json":    "application/json",
+	"csv":     "text/csv",
+	"xml":     "application/xml",
+	"geojson": "application/geo+json",
+	"rdf":     "application/rdf+xml",
+	"jsonld":  "application/ld+json",
+}
+
+// dcatDistributions returns one dcat:Distribution per representation actually
+// available for the dataset: the JSON API itself, every additional Output
+// format the upstream API gives a URL of its own, and the Swagger
+// documentation. Formats without their own URL are left out, as they cannot
+// be accessed at any URL the catalog knows. Each is identified by a fragment
+// of the dataset IRI and carries the dct:license of the dataset, if licenses
+// map it to a license URI.
+func dcatDistributions(licenses Licenses, ds Dataset) []Distribution {
+	var out []Distribution
+	if ds.ApiUrl != "" {
+		out = append(out, dcatDistribution(ds, "json", ds.ApiUrl, ds.Shortname+" API Endpoint", "application/json"))
+		for _, format := range outputFormats(ds) {
+			if format.name == "json" || format.url == "" {
+				continue
+			}
+			mediaType, ok := mediaTypes[format.name]
+			if !ok {
+				mediaType = format.name
+			}
+			out = append(out, dcatDistribution(ds, format.name, format.url, ds.Shortname+" "+strings.ToUpper(format.name)+" Export", mediaType))
+		}
+	}
+	if ds.SwaggerUrl != "" {
+		out = append(out, dcatDistribution(ds, "swagger", ds.SwaggerUrl, ds.Shortname+" API Documentation", "application/vnd.oai.openapi+json"))
+	}
+	if l, ok := licenses.of(ds); ok && l.URI != "" {
+		for i := range out {
+			out[i].License = &Ref{ID: l.URI}
+		}
+	}
+	return out
+}
+
+// dcatDistribution returns the distribution key of ds, identified by
+// ds.Self + "#distribution-" + key and accessed at accessURL.
+func dcatDistribution(ds Dataset, key, accessURL, title, mediaType string) Distribution {
+	id := ds.Self + "#distribution-" + key
+	return Distribution{
+		Type:       "dcat:Distribution",
+		ID:         id,
+		Identifier: id,
+		DCTType:    LangMap{"en": "dcat:Distribution"},
+		Title:      LangMap{"en": title},
+		Format:     mediaType,
+		AccessURL:  accessURL,
+	}
+}
+
+// outputFormat is an upstream Output format of a dataset and the URL it is
+// served at, empty if the upstream API gives none.
+type outputFormat struct {
+	name string
+	url  string
+}
+
+// outputFormats normalizes the loosely typed upstream Output field (a list or a
+// comma-separated string of format names, or a map of format names to their
+// URLs) into lower-case, de-duplicated formats.
+func outputFormats(ds Dataset) []outputFormat {
+	var raw []outputFormat
+	switch v := ds.Output.(type) {
+	case string:
+		for _, name := range strings.Split(v, ",") {
+			raw = append(raw, outputFormat{name: name})
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, outputFormat{name: s})
+			}
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			u, _ := value.(string)
+			raw = append(raw, outputFormat{name: key, url: strings.TrimSpace(u)})
+		}
+		sort.Slice(raw, func(i, j int) bool { return raw[i].name < raw[j].name })
+	}
+	seen := map[string]bool{}
+	var out []outputFormat
+	for _, f := range raw {
+		f.name = strings.ToLower(strings.TrimSpace(f.name))
+		if f.name != "" && !seen[f.name] {
+			seen[f.name] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// ToDCATDataspace maps the datasets of one dataspace (tourism, mobility, ...) to
+// a catalog of its own, with a dataspace-specific @id, title and publisher, that
+// is linked to the main catalog via dct:isPartOf. The title, description and
+// publisher name are those of the main catalog and publisher, followed by the
+// dataspace name, in every language the publisher configures.
+func ToDCATDataspace(p Publisher, dataspace string, datasets []Dataset, lang string) *Catalog {
+	name := dataspace
+	if dataspace != "" {
+		name = strings.ToUpper(dataspace[:1]) + dataspace[1:]
+	}
+	catalog := ToDCAT(p, datasets, lang)
+	catalog.ID = p.BaseURL + "dcat/dataspace/" + dataspace
+	catalog.Identifier = "catalog-001-" + dataspace
+	catalog.Title = LangMap{}
+	for l, title := range p.CatalogTitle() {
+		catalog.Title[l] = title + ": " + name
+	}
+	catalog.Description = LangMap{}
+	for l, description := range p.CatalogDescription() {
+		catalog.Description[l] = description + " (" + name + ")"
+	}
+	catalog.IsPartOf = &Ref{ID: p.BaseURL + "api-catalog"}
+	catalog.Publisher = Organization{
+		Type:       "foaf:Organization",
+		Identifier: "org-001-" + dataspace,
+		Title:      LangMap{},
+		Homepage:   p.URL,
+	}
+	for l := range catalog.Title {
+		catalog.Publisher.Title[l] = p.Name + " " + name
+	}
+	return catalog
+}
@@ -0,0 +1,15 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import "testing"
+
+func BenchmarkToDCAT(b *testing.B) {
+	datasets := benchDatasets(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ToDCAT(DefaultPublisher, datasets, DefaultLanguage)
+	}
+}
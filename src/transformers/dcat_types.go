@@ -0,0 +1,65 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+// LangMap is a JSON-LD language map: a text by language tag.
+type LangMap map[string]string
+
+// Ref references a node by its IRI.
+type Ref struct {
+	ID string `json:"@id" yaml:"@id" toml:"@id"`
+}
+
+// Catalog is a dcat:Catalog, as built by ToDCAT and DCATCatalog.
+type Catalog struct {
+	Context     map[string]interface{} `json:"@context" yaml:"@context" toml:"@context"`
+	Type        string                 `json:"@type" yaml:"@type" toml:"@type"`
+	ID          string                 `json:"@id" yaml:"@id" toml:"@id"`
+	DCTType     LangMap                `json:"dct:type" yaml:"dct:type" toml:"dct:type"`
+	Identifier  string                 `json:"dct:identifier" yaml:"dct:identifier" toml:"dct:identifier"`
+	Title       LangMap                `json:"dct:title" yaml:"dct:title" toml:"dct:title"`
+	Description LangMap                `json:"dct:description" yaml:"dct:description" toml:"dct:description"`
+	Issued      string                 `json:"dct:issued" yaml:"dct:issued" toml:"dct:issued"`
+	Modified    string                 `json:"dct:modified" yaml:"dct:modified" toml:"dct:modified"`
+	IsPartOf    *Ref                   `json:"dct:isPartOf,omitempty" yaml:"dct:isPartOf,omitempty" toml:"dct:isPartOf,omitempty"`
+	Publisher   Organization           `json:"publisher" yaml:"publisher" toml:"publisher"`
+	Datasets    []DCATDataset          `json:"dataset,omitempty" yaml:"dataset,omitempty" toml:"dataset,omitempty"`
+	// Links are the pagination links of a paginated catalog.
+	Links map[string]interface{} `json:"links,omitempty" yaml:"links,omitempty" toml:"links,omitempty"`
+}
+
+// Organization is the foaf:Organization publishing a catalog.
+type Organization struct {
+	Type       string  `json:"@type" yaml:"@type" toml:"@type"`
+	Identifier string  `json:"dct:identifier" yaml:"dct:identifier" toml:"dct:identifier"`
+	Title      LangMap `json:"dct:title" yaml:"dct:title" toml:"dct:title"`
+	Homepage   string  `json:"homepage" yaml:"homepage" toml:"homepage"`
+}
+
+// DCATDataset is a dcat:Dataset, as built by ToDCATDataset.
+type DCATDataset struct {
+	Type          string         `json:"@type" yaml:"@type" toml:"@type"`
+	ID            string         `json:"@id" yaml:"@id" toml:"@id"`
+	Identifier    string         `json:"dct:identifier" yaml:"dct:identifier" toml:"dct:identifier"`
+	DCTType       LangMap        `json:"dct:type" yaml:"dct:type" toml:"dct:type"`
+	Title         LangMap        `json:"dct:title" yaml:"dct:title" toml:"dct:title"`
+	Description   LangMap        `json:"dct:description" yaml:"dct:description" toml:"dct:description"`
+	Language      string         `json:"dct:language" yaml:"dct:language" toml:"dct:language"`
+	Issued        string         `json:"dct:issued,omitempty" yaml:"dct:issued,omitempty" toml:"dct:issued,omitempty"`
+	Modified      string         `json:"dct:modified,omitempty" yaml:"dct:modified,omitempty" toml:"dct:modified,omitempty"`
+	Deprecated    bool           `json:"owl:deprecated" yaml:"owl:deprecated" toml:"owl:deprecated"`
+	Distributions []Distribution `json:"distribution,omitempty" yaml:"distribution,omitempty" toml:"distribution,omitempty"`
+}
+
+// Distribution is a dcat:Distribution of a dataset.
+type Distribution struct {
+	Type       string  `json:"@type" yaml:"@type" toml:"@type"`
+	ID         string  `json:"@id" yaml:"@id" toml:"@id"`
+	Identifier string  `json:"dct:identifier" yaml:"dct:identifier" toml:"dct:identifier"`
+	DCTType    LangMap `json:"dct:type" yaml:"dct:type" toml:"dct:type"`
+	Title      LangMap `json:"dct:title" yaml:"dct:title" toml:"dct:title"`
+	Format     string  `json:"dct:format" yaml:"dct:format" toml:"dct:format"`
+	AccessURL  string  `json:"accessURL" yaml:"accessURL" toml:"accessURL"`
+	License    *Ref    `json:"dct:license,omitempty" yaml:"dct:license,omitempty" toml:"dct:license,omitempty"`
+}
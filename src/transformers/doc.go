@@ -0,0 +1,14 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+// Package transformers maps datasets of the Open Data Hub MetaData API to the
+// documents the catalog publishes: DCAT (JSON-LD and Turtle), ODPS 1.0, 3.0
+// and 3.1, VoID, JSON:API resources and Markdown.
+//
+// It does not depend on the HTTP server or the upstream client, so other Go
+// services can import it to build these documents from datasets they fetched
+// themselves:
+//
+//	var datasets []transformers.Dataset // decoded from the MetaData API
+//	doc := transformers.ToDCAT(transformers.DefaultPublisher, datasets, "en")
+package transformers
@@ -0,0 +1,51 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+// ToJSONAPIResource maps a dataset to a JSON:API resource object of type "datasets".
+// When fields is non-empty, only the listed attributes are included (sparse fieldsets).
+func ToJSONAPIResource(p Publisher, ds Dataset, fields []string) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"shortname":   ds.Shortname,
+		"type":        ds.Type,
+		"description": ds.ApiDescription,
+		"apiUrl":      ds.ApiUrl,
+		"swaggerUrl":  ds.SwaggerUrl,
+		"dataspace":   ds.Dataspace,
+		"category":    ds.Category,
+		"deprecated":  ds.Deprecated,
+		"license":     ds.LicenseInfo.License,
+		"firstImport": ds.FirstImport,
+		"lastChange":  ds.LastChange,
+		"recordCount": ds.RecordCount,
+	}
+	if len(fields) > 0 {
+		sparse := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if v, ok := attributes[f]; ok {
+				sparse[f] = v
+			}
+		}
+		attributes = sparse
+	}
+
+	providers := []map[string]string{}
+	for _, p := range ds.DataProvider {
+		providers = append(providers, map[string]string{"type": "dataProviders", "id": p})
+	}
+
+	return map[string]interface{}{
+		"type":       "datasets",
+		"id":         ds.ID,
+		"attributes": attributes,
+		"relationships": map[string]interface{}{
+			"dataProviders": map[string]interface{}{
+				"data": providers,
+			},
+		},
+		"links": map[string]string{
+			"self": p.BaseURL + "jsonapi/datasets/" + ds.ID,
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// License is the canonical name and URI of a license. Proprietary licenses
+// have no URI.
+type License struct {
+	Name string `yaml:"name"`
+	URI  string `yaml:"uri"`
+}
+
+// Licenses maps the upstream LicenseInfo.License values, lower-cased, to the
+// licenses they stand for. A nil Licenses maps builtinLicenses.
+type Licenses map[string]License
+
+// builtinLicenses are the licenses mapped by default. LoadLicenses adds to and
+// overrides them.
+var builtinLicenses = Licenses{
+	"cc0":       {Name: "CC0 1.0", URI: "https://creativecommons.org/publicdomain/zero/1.0/"},
+	"cc0-1.0":   {Name: "CC0 1.0", URI: "https://creativecommons.org/publicdomain/zero/1.0/"},
+	"cc-by":     {Name: "CC BY 4.0", URI: "https://creativecommons.org/licenses/by/4.0/"},
+	"cc-by-4.0": {Name: "CC BY 4.0", URI: "https://creativecommons.org/licenses/by/4.0/"},
+	"cc-by-sa":  {Name: "CC BY-SA 4.0", URI: "https://creativecommons.org/licenses/by-sa/4.0/"},
+	"closed":    {Name: "Proprietary"},
+}
+
+// LoadLicenses loads the license mapping from the YAML file at path, a map
+// from upstream license value to the name and URI it stands for:
+//
+//	CC0:
+//	  name: CC0 1.0 Universal
+//	  uri: https://creativecommons.org/publicdomain/zero/1.0/
+//
+// Values are matched case-insensitively; entries replace the built-in ones.
+func LoadLicenses(path string) (Licenses, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var loaded map[string]License
+	if err := yaml.Unmarshal(body, &loaded); err != nil {
+		return nil, err
+	}
+	licenses := maps.Clone(builtinLicenses)
+	for value, l := range loaded {
+		if l.Name == "" {
+			return nil, fmt.Errorf("license %q: name is required", value)
+		}
+		licenses[strings.ToLower(strings.TrimSpace(value))] = l
+	}
+	log.Printf("Loaded %d license mappings from %s", len(loaded), path)
+	return licenses, nil
+}
+
+// of returns the license of ds. Values missing from the mapping are returned
+// as the name without URI; ok is false if ds has no license.
+func (m Licenses) of(ds Dataset) (l License, ok bool) {
+	value := strings.TrimSpace(ds.LicenseInfo.License)
+	if value == "" {
+		return License{}, false
+	}
+	if m == nil {
+		m = builtinLicenses
+	}
+	if l, ok := m[strings.ToLower(value)]; ok {
+		return l, true
+	}
+	return License{Name: value}, true
+}
+
+// definition returns the license of ds for the scope of the ODPS license
+// section: its URI, or its name if it has none, or "Full access" for datasets
+// without a license.
+func (m Licenses) definition(ds Dataset) string {
+	l, ok := m.of(ds)
+	switch {
+	case !ok:
+		return "Full access"
+	case l.URI != "":
+		return l.URI
+	}
+	return l.Name
+}
@@ -0,0 +1,63 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToMarkdown renders datasets as a human-readable Markdown inventory with one
+// section per dataset, suitable for wikis and READMEs.
+func ToMarkdown(p Publisher, datasets []Dataset) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", localized(p.CatalogTitle(), DefaultLanguage))
+	for _, ds := range datasets {
+		fmt.Fprintf(&b, "## %s\n\n", ds.Shortname)
+		if desc := ds.ApiDescription["en"]; desc != "" {
+			fmt.Fprintf(&b, "%s\n\n", desc)
+		}
+		fmt.Fprintf(&b, "- **ID:** `%s`\n", ds.ID)
+		fmt.Fprintf(&b, "- **Type:** %s\n", ds.Type)
+		if ds.ApiUrl != "" {
+			fmt.Fprintf(&b, "- **API:** <%s>\n", ds.ApiUrl)
+		}
+		if ds.SwaggerUrl != "" {
+			fmt.Fprintf(&b, "- **Documentation:** <%s>\n", ds.SwaggerUrl)
+		}
+		fmt.Fprintf(&b, "- **ODPS:** <%sodps31/%s>\n", p.BaseURL, ds.ID)
+		license := "n/a"
+		if l, ok := p.Licenses.of(ds); ok {
+			license = l.Name
+			if l.URI != "" {
+				license += " <" + l.URI + ">"
+			}
+		}
+		fmt.Fprintf(&b, "- **License:** %s\n", license)
+		if tags := markdownTags(ds); len(tags) > 0 {
+			fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(tags, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// markdownTags collects the categories and ODH tag IDs of a dataset as inline code spans.
+func markdownTags(ds Dataset) []string {
+	var tags []string
+	for _, c := range ds.Category {
+		tags = append(tags, "`"+c+"`")
+	}
+	for _, t := range ds.ODHTags {
+		switch tag := t.(type) {
+		case string:
+			tags = append(tags, "`"+tag+"`")
+		case map[string]interface{}:
+			if id, ok := tag["Id"].(string); ok {
+				tags = append(tags, "`"+id+"`")
+			}
+		}
+	}
+	return tags
+}
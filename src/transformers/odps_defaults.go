@@ -0,0 +1,189 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// odpsSections are the sections of the ODPS 3.0 and 3.1 documents that the
+// upstream catalog has no data for and that LoadODPSDefaults can configure,
+// each with a constructor for a value of its type.
+var odpsSections = map[string]func() interface{}{
+	"pricingPlans": func() interface{} { return new([]PricingPlan) },
+	"SLA":          func() interface{} { return new([]Objective) },
+	"dataQuality":  func() interface{} { return new([]Objective) },
+	"dataOps":      func() interface{} { return new(DataOps) },
+	"dataAccess":   func() interface{} { return new(DataAccess) },
+	"support":      func() interface{} { return new(Support) },
+	"license":      func() interface{} { return new(ProductLicense) },
+}
+
+// odpsDefaultsFile is the format of the file loaded by LoadODPSDefaults.
+type odpsDefaultsFile struct {
+	Defaults   map[string]interface{}            `yaml:"defaults"`
+	Dataspaces map[string]map[string]interface{} `yaml:"dataspaces"`
+}
+
+// ODPSDefaults are the ODPS sections loaded by LoadODPSDefaults. With the zero
+// value, the ODPS documents carry the built-in placeholder values.
+type ODPSDefaults struct {
+	defaults   map[string]interface{}
+	dataspaces map[string]map[string]interface{}
+}
+
+// LoadODPSDefaults loads the ODPS sections from the YAML file at path: a
+// defaults map from section name (one of odpsSections) to its value, and a
+// dataspaces map overriding sections for the datasets of a dataspace. Objects
+// are merged key by key into the built-in section, so a file can set e.g.
+// only dataOps.build.checksum; lists and other values replace it. Keys the
+// section does not have are rejected.
+//
+// String values containing {{ are Go templates executed with the Dataset,
+// e.g. "{{ .SwaggerUrl }}" or "{{ .Self }}/monitoring".
+func LoadODPSDefaults(path string) (ODPSDefaults, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return ODPSDefaults{}, err
+	}
+	var loaded odpsDefaultsFile
+	if err := yaml.Unmarshal(body, &loaded); err != nil {
+		return ODPSDefaults{}, err
+	}
+	if err := checkODPSSections(loaded.Defaults); err != nil {
+		return ODPSDefaults{}, fmt.Errorf("defaults: %w", err)
+	}
+	for dataspace, sections := range loaded.Dataspaces {
+		if err := checkODPSSections(sections); err != nil {
+			return ODPSDefaults{}, fmt.Errorf("dataspace %s: %w", dataspace, err)
+		}
+	}
+	log.Printf("Loaded ODPS defaults from %s (%d dataspace overrides)", path, len(loaded.Dataspaces))
+	return ODPSDefaults{defaults: loaded.Defaults, dataspaces: loaded.Dataspaces}, nil
+}
+
+// checkODPSSections rejects sections that are not in odpsSections or do not
+// fit their type, which are most likely typos that would otherwise be silently
+// ignored, and parses the templates of the others.
+func checkODPSSections(sections map[string]interface{}) error {
+	for name, section := range sections {
+		newSection, ok := odpsSections[name]
+		if !ok {
+			return fmt.Errorf("unknown section %q", name)
+		}
+		if err := decodeSection(section, newSection(), true); err != nil {
+			return fmt.Errorf("section %s: %w", name, err)
+		}
+		parsed, err := parseODPSTemplates(name, section)
+		if err != nil {
+			return err
+		}
+		sections[name] = parsed
+	}
+	return nil
+}
+
+// parseODPSTemplates returns v with the strings containing {{ replaced by
+// their parsed templates. Each template is executed once with an empty
+// Dataset, so that misspelt fields fail at startup rather than per request;
+// other errors depend on the dataset and are only logged when executed.
+func parseODPSTemplates(path string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New(path).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		err = tmpl.Execute(&strings.Builder{}, Dataset{})
+		if err != nil && strings.Contains(err.Error(), "can't evaluate field") {
+			return nil, err
+		}
+		return tmpl, nil
+	case map[string]interface{}:
+		for k, item := range v {
+			parsed, err := parseODPSTemplates(path+"."+k, item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = parsed
+		}
+	case []interface{}:
+		for i, item := range v {
+			parsed, err := parseODPSTemplates(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = parsed
+		}
+	}
+	return v, nil
+}
+
+// executeODPSTemplates returns a copy of v with its templates executed with
+// ds. A template failing on ds leaves its field empty.
+func executeODPSTemplates(v interface{}, ds Dataset) interface{} {
+	switch v := v.(type) {
+	case *template.Template:
+		var out strings.Builder
+		if err := v.Execute(&out, ds); err != nil {
+			log.Printf("Error executing ODPS template %s for %s: %v", v.Name(), ds.ID, err)
+			return ""
+		}
+		return out.String()
+	case map[string]interface{}:
+		executed := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			executed[k] = executeODPSTemplates(item, ds)
+		}
+		return executed
+	case []interface{}:
+		executed := make([]interface{}, len(v))
+		for i, item := range v {
+			executed[i] = executeODPSTemplates(item, ds)
+		}
+		return executed
+	}
+	return v
+}
+
+// odpsSection returns the named section of the ODPS document of ds: builtin,
+// overridden by the defaults d and then by those of its dataspace.
+func odpsSection[T any](d ODPSDefaults, ds Dataset, name string, builtin T) T {
+	section := builtin
+	overrides := []map[string]interface{}{d.defaults, d.dataspaces[ds.Dataspace]}
+	for _, sections := range overrides {
+		v, ok := sections[name]
+		if !ok {
+			continue
+		}
+		if err := decodeSection(executeODPSTemplates(v, ds), &section, false); err != nil {
+			log.Printf("Error applying ODPS %s defaults for %s: %v", name, ds.ID, err)
+		}
+	}
+	return section
+}
+
+// decodeSection decodes the generic section v into out, which points to a
+// value of its type. Fields missing from v keep their value in out, so that
+// objects are merged into it; lists are replaced. With strict, keys out has
+// no field for are an error.
+func decodeSection(v interface{}, out interface{}, strict bool) error {
+	body, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(body))
+	dec.KnownFields(strict)
+	return dec.Decode(out)
+}
@@ -0,0 +1,178 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import "fmt"
+
+// The builders below return the sections shared by the ODPS 3.0 and 3.1
+// documents of ds. Those the upstream catalog has no data for carry
+// placeholder values, overridden by the ODPS defaults, see LoadODPSDefaults.
+
+func odpsProductDetails(p Publisher, ds Dataset, lang string) ProductDetails {
+	return ProductDetails{
+		Name:              ds.Shortname,
+		ProductID:         ds.ID,
+		ValueProposition:  fmt.Sprintf("A tailored data product for %s data", ds.Type),
+		Description:       ds.ApiDescription[lang],
+		ProductSeries:     ds.Shortname + " Series",
+		Visibility:        "public",
+		Status:            productStatus(ds),
+		Version:           "v1.0",
+		Categories:        ds.Category,
+		Standards:         []string{"Standard-Dev"},
+		Tags:              []string{},
+		BrandSlogan:       p.Slogan,
+		Type:              ds.Type,
+		LogoURL:           ds.Self,
+		OutputFileFormats: []string{"JSON", "YAML"},
+		UseCases: []UseCaseItem{
+			{UseCase: UseCase{
+				Title:       "Discover Insights - example",
+				Description: "description example",
+				URL:         ds.ApiUrl + "/usecase/insights",
+			}},
+		},
+	}
+}
+
+func odpsRecommendedDataProducts(ds Dataset) []string {
+	return []string{ds.Self + "/recommended/1", ds.Self + "/recommended/2"}
+}
+
+func odpsPricingPlans(p Publisher, ds Dataset, lang string) map[string][]PricingPlan {
+	return map[string][]PricingPlan{
+		lang: odpsSection(p.ODPSDefaults, ds, "pricingPlans", []PricingPlan{{
+			Name:                   "Free",
+			PriceCurrency:          "EUR",
+			Price:                  "0",
+			BillingDuration:        "Monthly",
+			Unit:                   "month",
+			MaxTransactionQuantity: "1000",
+			Offering:               []string{"Basic"},
+		}}),
+	}
+}
+
+func odpsDataOps(p Publisher, ds Dataset) DataOps {
+	return odpsSection(p.ODPSDefaults, ds, "dataOps", DataOps{
+		Data: DataOpsData{SchemaLocationURL: ds.Self + "/schema"},
+		Lineage: DataOpsLineage{
+			DataLineageTool:   "LineageTool",
+			DataLineageOutput: "LineageInfo",
+		},
+		Infrastructure: DataOpsInfrastructure{
+			ContainerTool:     "Docker",
+			Platform:          "Kubernetes",
+			Region:            "eu-south-1",
+			StorageTechnology: "S3",
+			StorageType:       "Object",
+		},
+		Build: DataOpsBuild{
+			Format:                     "docker",
+			HashType:                   "SHA256",
+			Checksum:                   "abc123",
+			SignatureType:              "PGP",
+			ScriptURL:                  ds.Self + "/build.sh",
+			DeploymentDocumentationURL: ds.Self + "/deploy",
+		},
+	})
+}
+
+// odpsDataAccess takes the documentation URL, which differs between the
+// ODPS 3.0 and 3.1 documents.
+func odpsDataAccess(p Publisher, ds Dataset, documentationURL string) DataAccess {
+	return odpsSection(p.ODPSDefaults, ds, "dataAccess", DataAccess{
+		Type:                 "REST",
+		AuthenticationMethod: "None",
+		Specification:        "OpenAPI",
+		Format:               "JSON",
+		DocumentationURL:     documentationURL,
+	})
+}
+
+func odpsSLA(p Publisher, ds Dataset) []Objective {
+	return odpsSection(p.ODPSDefaults, ds, "SLA", []Objective{{
+		Dimension:    "Availability",
+		DisplayTitle: []LangMap{{"en": "Availability"}},
+		Target:       99.9,
+		Unit:         "%",
+		Monitoring: Monitoring{
+			Type:      "Service Level",
+			Reference: ds.Self + "/monitoring",
+			Spec:      "SLA Spec",
+		},
+	}})
+}
+
+func odpsSupport(p Publisher, ds Dataset) Support {
+	return odpsSection(p.ODPSDefaults, ds, "support", Support{
+		PhoneNumber:       p.ContactPhone,
+		PhoneServiceHours: "9-5",
+		Email:             p.ContactEmail,
+		EmailServiceHours: "9-5",
+		DocumentationURL:  ds.SwaggerUrl,
+	})
+}
+
+func odpsDataQuality(p Publisher, ds Dataset) []Objective {
+	return odpsSection(p.ODPSDefaults, ds, "dataQuality", []Objective{{
+		Dimension:    "Accuracy",
+		DisplayTitle: []LangMap{{"en": "Accuracy"}},
+		Target:       95.0,
+		Unit:         "%",
+		Monitoring: Monitoring{
+			Type:      "Quality",
+			Reference: ds.Self + "/quality",
+			Spec:      "Quality Spec",
+		},
+	}})
+}
+
+func odpsLicense(p Publisher, ds Dataset) ProductLicense {
+	return odpsSection(p.ODPSDefaults, ds, "license", ProductLicense{
+		Scope: LicenseScope{
+			Definition:       p.Licenses.definition(ds),
+			Language:         "en",
+			Restrictions:     "None",
+			GeographicalArea: []string{"Global"},
+			Permanent:        true,
+			Exclusive:        false,
+			Rights:           []string{"Read", "Write"},
+		},
+		Termination: LicenseTermination{
+			TerminationConditions: "Violation of terms",
+			ContinuityConditions:  "N/A",
+		},
+		Governance: LicenseGovernance{
+			Ownership:       p.Name,
+			Damages:         "None",
+			Confidentiality: "High",
+			ApplicableLaws:  "GDPR",
+			Warranties:      "None",
+			Audit:           "Annual",
+			ForceMajeure:    "Standard",
+		},
+	})
+}
+
+func odpsDataHolder(p Publisher, ds Dataset) DataHolder {
+	return DataHolder{
+		TaxID:              p.TaxID,
+		VatID:              p.VatID,
+		BusinessDomain:     "Data",
+		LogoURL:            ds.Self,
+		Description:        p.Slogan,
+		URL:                ds.Self,
+		Telephone:          p.ContactPhone,
+		StreetAddress:      p.StreetAddress,
+		PostalCode:         p.PostalCode,
+		AddressRegion:      p.Region,
+		AddressLocality:    p.Locality,
+		AddressCountry:     p.Country,
+		AggregateRating:    "5 stars",
+		RatingCount:        100,
+		Slogan:             p.Slogan,
+		ParentOrganization: p.Name,
+	}
+}
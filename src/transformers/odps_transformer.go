@@ -5,42 +5,48 @@ package transformers
 
 import "fmt"
 
-// ToODPS maps datasets to an ODPS v1.0 structure.
-func ToODPS(datasets []Dataset) map[string]interface{} {
+// ToODPS maps datasets to an ODPS v1.0 structure, with the catalog title and
+// description in lang.
+func ToODPS(p Publisher, datasets []Dataset, lang string) map[string]interface{} {
 	var apiList []map[string]interface{}
 	for _, ds := range datasets {
-		apiList = append(apiList, map[string]interface{}{
+		api := map[string]interface{}{
 			"id":          ds.ID,
 			"title":       ds.Shortname,
 			"description": fmt.Sprintf("Dataset type: %s", ds.Type),
 			"version":     "v1",
+			"status":      productStatus(ds),
 			"contact": map[string]interface{}{
-				"name":  "Support Open Data Hub",
-				"email": ContactEmail,
+				"name":  p.ContactName,
+				"email": p.ContactEmail,
 			},
 			"endpoints": []map[string]interface{}{
 				{
 					"url":           ds.ApiUrl,
 					"methods":       []string{"GET"},
 					"formats":       []string{"application/json"},
-					"documentation": ContactWebsite,
+					"documentation": p.ContactWebsite,
 				},
 			},
-			"license": map[string]interface{}{
-				"name": "CC BY 4.0",
-				"url":  "https://creativecommons.org/licenses/by/4.0/",
-			},
-		})
+		}
+		if l, ok := p.Licenses.of(ds); ok {
+			license := map[string]interface{}{"name": l.Name}
+			if l.URI != "" {
+				license["url"] = l.URI
+			}
+			api["license"] = license
+		}
+		apiList = append(apiList, api)
 	}
 
 	return map[string]interface{}{
 		"odps": "1.0",
 		"catalog": map[string]interface{}{
-			"title":       "API Catalog",
-			"description": "A catalog of APIs provided by " + OrganizationName + ".",
+			"title":       localized(p.CatalogTitle(), lang),
+			"description": localized(p.CatalogDescription(), lang),
 			"publisher": map[string]interface{}{
-				"name": OrganizationName,
-				"url":  OrganizationURL,
+				"name": p.Name,
+				"url":  p.URL,
 			},
 			"apis": apiList,
 		},
@@ -0,0 +1,210 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+// ODPS30Document is an ODPS v3.0 (dev) document, as built by ToODPS30.
+type ODPS30Document struct {
+	Schema                  string                    `json:"schema" yaml:"schema" toml:"schema"`
+	Version                 string                    `json:"version" yaml:"version" toml:"version"`
+	Product                 map[string]ProductDetails `json:"product" yaml:"product" toml:"product"`
+	RecommendedDataProducts []string                  `json:"recommendedDataProducts" yaml:"recommendedDataProducts" toml:"recommendedDataProducts"`
+	PricingPlans            map[string][]PricingPlan  `json:"pricingPlans" yaml:"pricingPlans" toml:"pricingPlans"`
+	DataOps                 DataOps                   `json:"dataOps" yaml:"dataOps" toml:"dataOps"`
+	DataAccess              DataAccess                `json:"dataAccess" yaml:"dataAccess" toml:"dataAccess"`
+	SLA                     []Objective               `json:"SLA" yaml:"SLA" toml:"SLA"`
+	Support                 Support                   `json:"support" yaml:"support" toml:"support"`
+	DataQuality             []Objective               `json:"dataQuality" yaml:"dataQuality" toml:"dataQuality"`
+	License                 ProductLicense            `json:"license" yaml:"license" toml:"license"`
+	DataHolder              DataHolder                `json:"dataHolder" yaml:"dataHolder" toml:"dataHolder"`
+}
+
+// ODPS31Document is an ODPS v3.1 document, as built by ToODPS31. Its product
+// holds the sections below and, under the language key, the ProductDetails,
+// so it is a map rather than a struct.
+type ODPS31Document struct {
+	Schema   string                 `json:"schema" yaml:"schema" toml:"schema"`
+	Version  string                 `json:"version" yaml:"version" toml:"version"`
+	Product  map[string]interface{} `json:"product" yaml:"product" toml:"product"`
+	Details  ODPS31Details          `json:"details" yaml:"details" toml:"details"`
+	Issued   string                 `json:"dct:issued" yaml:"dct:issued" toml:"dct:issued"`
+	Modified string                 `json:"dct:modified" yaml:"dct:modified" toml:"dct:modified"`
+}
+
+// ODPS31Details summarizes the dataset an ODPS v3.1 document describes.
+type ODPS31Details struct {
+	Summary     string   `json:"summary" yaml:"summary" toml:"summary"`
+	Description string   `json:"description" yaml:"description" toml:"description"`
+	Language    string   `json:"language" yaml:"language" toml:"language"`
+	Metadata    MetaData `json:"metadata" yaml:"metadata" toml:"metadata"`
+}
+
+// ProductDetails are the localized details of a data product.
+type ProductDetails struct {
+	Name              string        `json:"name" yaml:"name" toml:"name"`
+	ProductID         string        `json:"productID" yaml:"productID" toml:"productID"`
+	ValueProposition  string        `json:"valueProposition" yaml:"valueProposition" toml:"valueProposition"`
+	Description       string        `json:"description" yaml:"description" toml:"description"`
+	ProductSeries     string        `json:"productSeries" yaml:"productSeries" toml:"productSeries"`
+	Visibility        string        `json:"visibility" yaml:"visibility" toml:"visibility"`
+	Status            string        `json:"status" yaml:"status" toml:"status"`
+	Version           string        `json:"version" yaml:"version" toml:"version"`
+	Categories        []string      `json:"categories" yaml:"categories" toml:"categories"`
+	Standards         []string      `json:"standards" yaml:"standards" toml:"standards"`
+	Tags              []string      `json:"tags" yaml:"tags" toml:"tags"`
+	BrandSlogan       string        `json:"brandSlogan" yaml:"brandSlogan" toml:"brandSlogan"`
+	Type              string        `json:"type" yaml:"type" toml:"type"`
+	LogoURL           string        `json:"logoURL" yaml:"logoURL" toml:"logoURL"`
+	OutputFileFormats []string      `json:"OutputFileFormats" yaml:"OutputFileFormats" toml:"OutputFileFormats"`
+	UseCases          []UseCaseItem `json:"useCases" yaml:"useCases" toml:"useCases"`
+}
+
+// UseCaseItem wraps a use case of a data product.
+type UseCaseItem struct {
+	UseCase UseCase `json:"useCase" yaml:"useCase" toml:"useCase"`
+}
+
+// UseCase is an example use of a data product.
+type UseCase struct {
+	Title       string `json:"useCaseTitle" yaml:"useCaseTitle" toml:"useCaseTitle"`
+	Description string `json:"useCaseDescription" yaml:"useCaseDescription" toml:"useCaseDescription"`
+	URL         string `json:"useCaseURL" yaml:"useCaseURL" toml:"useCaseURL"`
+}
+
+// PricingPlan is a pricing plan of a data product.
+type PricingPlan struct {
+	Name                   string   `json:"name" yaml:"name" toml:"name"`
+	PriceCurrency          string   `json:"priceCurrency" yaml:"priceCurrency" toml:"priceCurrency"`
+	Price                  string   `json:"price" yaml:"price" toml:"price"`
+	BillingDuration        string   `json:"billingDuration" yaml:"billingDuration" toml:"billingDuration"`
+	Unit                   string   `json:"unit" yaml:"unit" toml:"unit"`
+	MaxTransactionQuantity string   `json:"maxTransactionQuantity" yaml:"maxTransactionQuantity" toml:"maxTransactionQuantity"`
+	Offering               []string `json:"offering" yaml:"offering" toml:"offering"`
+}
+
+// DataOps describes how a data product is built and run.
+type DataOps struct {
+	Data           DataOpsData           `json:"data" yaml:"data" toml:"data"`
+	Lineage        DataOpsLineage        `json:"lineage" yaml:"lineage" toml:"lineage"`
+	Infrastructure DataOpsInfrastructure `json:"infrastructure" yaml:"infrastructure" toml:"infrastructure"`
+	Build          DataOpsBuild          `json:"build" yaml:"build" toml:"build"`
+}
+
+// DataOpsData locates the schema of a data product.
+type DataOpsData struct {
+	SchemaLocationURL string `json:"schemaLocationURL" yaml:"schemaLocationURL" toml:"schemaLocationURL"`
+}
+
+// DataOpsLineage names the lineage tooling of a data product.
+type DataOpsLineage struct {
+	DataLineageTool   string `json:"dataLineageTool" yaml:"dataLineageTool" toml:"dataLineageTool"`
+	DataLineageOutput string `json:"dataLineageOutput" yaml:"dataLineageOutput" toml:"dataLineageOutput"`
+}
+
+// DataOpsInfrastructure describes where a data product runs.
+type DataOpsInfrastructure struct {
+	ContainerTool     string `json:"containerTool" yaml:"containerTool" toml:"containerTool"`
+	Platform          string `json:"platform" yaml:"platform" toml:"platform"`
+	Region            string `json:"region" yaml:"region" toml:"region"`
+	StorageTechnology string `json:"storageTechnology" yaml:"storageTechnology" toml:"storageTechnology"`
+	StorageType       string `json:"storageType" yaml:"storageType" toml:"storageType"`
+}
+
+// DataOpsBuild describes the build of a data product.
+type DataOpsBuild struct {
+	Format                     string `json:"format" yaml:"format" toml:"format"`
+	HashType                   string `json:"hashType" yaml:"hashType" toml:"hashType"`
+	Checksum                   string `json:"checksum" yaml:"checksum" toml:"checksum"`
+	SignatureType              string `json:"signatureType" yaml:"signatureType" toml:"signatureType"`
+	ScriptURL                  string `json:"scriptURL" yaml:"scriptURL" toml:"scriptURL"`
+	DeploymentDocumentationURL string `json:"deploymentDocumentationURL" yaml:"deploymentDocumentationURL" toml:"deploymentDocumentationURL"`
+}
+
+// DataAccess describes how the data of a product is accessed.
+type DataAccess struct {
+	Type                 string `json:"type" yaml:"type" toml:"type"`
+	AuthenticationMethod string `json:"authenticationMethod" yaml:"authenticationMethod" toml:"authenticationMethod"`
+	Specification        string `json:"specification" yaml:"specification" toml:"specification"`
+	Format               string `json:"format" yaml:"format" toml:"format"`
+	DocumentationURL     string `json:"documentationURL" yaml:"documentationURL" toml:"documentationURL"`
+}
+
+// Objective is a service level (SLA) or data quality objective.
+type Objective struct {
+	Dimension    string     `json:"dimension" yaml:"dimension" toml:"dimension"`
+	DisplayTitle []LangMap  `json:"displaytitle" yaml:"displaytitle" toml:"displaytitle"`
+	Target       float64    `json:"objective" yaml:"objective" toml:"objective"`
+	Unit         string     `json:"unit" yaml:"unit" toml:"unit"`
+	Monitoring   Monitoring `json:"monitoring" yaml:"monitoring" toml:"monitoring"`
+}
+
+// Monitoring describes how an objective is monitored.
+type Monitoring struct {
+	Type      string `json:"type" yaml:"type" toml:"type"`
+	Reference string `json:"reference" yaml:"reference" toml:"reference"`
+	Spec      string `json:"spec" yaml:"spec" toml:"spec"`
+}
+
+// Support lists the support channels of a data product.
+type Support struct {
+	PhoneNumber       string `json:"phoneNumber" yaml:"phoneNumber" toml:"phoneNumber"`
+	PhoneServiceHours string `json:"phoneServiceHours" yaml:"phoneServiceHours" toml:"phoneServiceHours"`
+	Email             string `json:"email" yaml:"email" toml:"email"`
+	EmailServiceHours string `json:"emailServiceHours" yaml:"emailServiceHours" toml:"emailServiceHours"`
+	DocumentationURL  string `json:"documentationURL" yaml:"documentationURL" toml:"documentationURL"`
+}
+
+// ProductLicense is the license section of a data product.
+type ProductLicense struct {
+	Scope       LicenseScope       `json:"scope" yaml:"scope" toml:"scope"`
+	Termination LicenseTermination `json:"termination" yaml:"termination" toml:"termination"`
+	Governance  LicenseGovernance  `json:"governance" yaml:"governance" toml:"governance"`
+}
+
+// LicenseScope is what a product license grants.
+type LicenseScope struct {
+	Definition       string   `json:"definition" yaml:"definition" toml:"definition"`
+	Language         string   `json:"language" yaml:"language" toml:"language"`
+	Restrictions     string   `json:"restrictions" yaml:"restrictions" toml:"restrictions"`
+	GeographicalArea []string `json:"geographicalArea" yaml:"geographicalArea" toml:"geographicalArea"`
+	Permanent        bool     `json:"permanent" yaml:"permanent" toml:"permanent"`
+	Exclusive        bool     `json:"exclusive" yaml:"exclusive" toml:"exclusive"`
+	Rights           []string `json:"rights" yaml:"rights" toml:"rights"`
+}
+
+// LicenseTermination is when a product license ends.
+type LicenseTermination struct {
+	TerminationConditions string `json:"terminationConditions" yaml:"terminationConditions" toml:"terminationConditions"`
+	ContinuityConditions  string `json:"continuityConditions" yaml:"continuityConditions" toml:"continuityConditions"`
+}
+
+// LicenseGovernance lists the legal terms of a product license.
+type LicenseGovernance struct {
+	Ownership       string `json:"ownership" yaml:"ownership" toml:"ownership"`
+	Damages         string `json:"damages" yaml:"damages" toml:"damages"`
+	Confidentiality string `json:"confidentiality" yaml:"confidentiality" toml:"confidentiality"`
+	ApplicableLaws  string `json:"applicableLaws" yaml:"applicableLaws" toml:"applicableLaws"`
+	Warranties      string `json:"warranties" yaml:"warranties" toml:"warranties"`
+	Audit           string `json:"audit" yaml:"audit" toml:"audit"`
+	ForceMajeure    string `json:"forceMajeure" yaml:"forceMajeure" toml:"forceMajeure"`
+}
+
+// DataHolder is the organisation holding the data of a product.
+type DataHolder struct {
+	TaxID              string `json:"taxID" yaml:"taxID" toml:"taxID"`
+	VatID              string `json:"vatID" yaml:"vatID" toml:"vatID"`
+	BusinessDomain     string `json:"businessDomain" yaml:"businessDomain" toml:"businessDomain"`
+	LogoURL            string `json:"logoURL" yaml:"logoURL" toml:"logoURL"`
+	Description        string `json:"description" yaml:"description" toml:"description"`
+	URL                string `json:"URL" yaml:"URL" toml:"URL"`
+	Telephone          string `json:"telephone" yaml:"telephone" toml:"telephone"`
+	StreetAddress      string `json:"streetAddress" yaml:"streetAddress" toml:"streetAddress"`
+	PostalCode         string `json:"postalCode" yaml:"postalCode" toml:"postalCode"`
+	AddressRegion      string `json:"addressRegion" yaml:"addressRegion" toml:"addressRegion"`
+	AddressLocality    string `json:"addressLocality" yaml:"addressLocality" toml:"addressLocality"`
+	AddressCountry     string `json:"addressCountry" yaml:"addressCountry" toml:"addressCountry"`
+	AggregateRating    string `json:"aggregateRating" yaml:"aggregateRating" toml:"aggregateRating"`
+	RatingCount        int    `json:"ratingCount" yaml:"ratingCount" toml:"ratingCount"`
+	Slogan             string `json:"slogan" yaml:"slogan" toml:"slogan"`
+	ParentOrganization string `json:"parentOrganization" yaml:"parentOrganization" toml:"parentOrganization"`
+}
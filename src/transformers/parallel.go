@@ -0,0 +1,42 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MapDatasets returns fn applied to every dataset, in the order of datasets.
+// The transformers are CPU-bound, so whole-catalog outputs transform their
+// datasets concurrently on up to GOMAXPROCS goroutines. fn must be safe for
+// concurrent use, which every To* function of this package is.
+func MapDatasets[T any](datasets []Dataset, fn func(Dataset) T) []T {
+	out := make([]T, len(datasets))
+	workers := min(runtime.GOMAXPROCS(0), len(datasets))
+	if workers <= 1 {
+		for i, ds := range datasets {
+			out[i] = fn(ds)
+		}
+		return out
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(datasets) {
+					return
+				}
+				out[i] = fn(datasets[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
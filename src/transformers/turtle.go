@@ -0,0 +1,275 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotJSONLD is returned by ToTurtle for documents without a JSON-LD @context.
+var ErrNotJSONLD = errors.New("document is not JSON-LD")
+
+// turtleTerms maps the unprefixed JSON-LD terms used by the transformers to their IRIs.
+var turtleTerms = map[string]string{
+	"dataset":      "dcat:dataset",
+	"distribution": "dcat:distribution",
+	"accessURL":    "dcat:accessURL",
+	"publisher":    "dct:publisher",
+	"homepage":     "foaf:homepage",
+}
+
+// turtleIRIValues lists properties whose string values are IRIs rather than literals.
+var turtleIRIValues = map[string]bool{
+	"dcat:accessURL": true,
+	"foaf:homepage":  true,
+}
+
+// ToTurtle serializes a JSON-LD document produced by this package (such as
+// ToDCAT or ToVoID output) as RDF Turtle. Prefixes come from the document's
+// @context; unprefixed terms without a known mapping are skipped.
+func ToTurtle(v interface{}) (string, error) {
+	w, err := writeTurtle(v)
+	if err != nil {
+		return "", err
+	}
+	return w.b.String(), nil
+}
+
+// countTriples returns the number of triples of the Turtle serialization of
+// the JSON-LD document v, or 0 if v is not JSON-LD.
+func countTriples(v interface{}) int {
+	w, err := writeTurtle(v)
+	if err != nil {
+		return 0
+	}
+	return w.triples
+}
+
+func writeTurtle(v interface{}) (*turtleWriter, error) {
+	doc, ok := jsonLDValue(reflect.ValueOf(v)).(map[string]interface{})
+	if !ok {
+		return nil, ErrNotJSONLD
+	}
+	context, ok := doc["@context"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotJSONLD
+	}
+	w := &turtleWriter{}
+	prefixes := make([]string, 0, len(context))
+	for prefix, iri := range context {
+		if s, ok := iri.(string); ok {
+			prefixes = append(prefixes, fmt.Sprintf("@prefix %s: <%s> .\n", prefix, s))
+		}
+	}
+	sort.Strings(prefixes)
+	w.b.WriteString(strings.Join(prefixes, ""))
+	w.node(doc)
+	return w, nil
+}
+
+type turtleWriter struct {
+	b strings.Builder
+	// triples counts the triples written.
+	triples int
+}
+
+// node writes a top-level subject block for n and, after it, blocks for every
+// nested node that has its own @id.
+func (w *turtleWriter) node(n map[string]interface{}) {
+	var nested []map[string]interface{}
+	w.b.WriteString("\n" + turtleSubject(n) + "\n")
+	w.predicates(n, "    ", &nested)
+	w.b.WriteString(" .\n")
+	for _, child := range nested {
+		w.node(child)
+	}
+}
+
+// predicates writes the predicate-object list of n. Nested nodes with an @id are
+// referenced and collected into nested; the others become blank nodes.
+func (w *turtleWriter) predicates(n map[string]interface{}, indent string, nested *[]map[string]interface{}) {
+	var lines []string
+	if t, ok := n["@type"].(string); ok {
+		lines = append(lines, indent+"a "+t)
+		w.triples++
+	}
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		predicate := turtlePredicate(key)
+		if predicate == "" {
+			continue
+		}
+		objects := w.objects(predicate, n[key], indent, nested)
+		if len(objects) > 0 {
+			lines = append(lines, indent+predicate+" "+strings.Join(objects, ", "))
+			w.triples += len(objects)
+		}
+	}
+	w.b.WriteString(strings.Join(lines, " ;\n"))
+}
+
+func (w *turtleWriter) objects(predicate string, value interface{}, indent string, nested *[]map[string]interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]string:
+		if id, ok := v["@id"]; ok {
+			return []string{"<" + id + ">"}
+		}
+		// Language map.
+		langs := make([]string, 0, len(v))
+		for lang := range v {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		var out []string
+		for _, lang := range langs {
+			out = append(out, turtleLiteral(v[lang])+"@"+lang)
+		}
+		return out
+	case map[string]interface{}:
+		if _, ok := v["@id"]; ok {
+			*nested = append(*nested, v)
+			return []string{turtleSubject(v)}
+		}
+		var sub turtleWriter
+		sub.predicates(v, indent+"    ", nested)
+		w.triples += sub.triples
+		return []string{"[\n" + sub.b.String() + "\n" + indent + "]"}
+	case []map[string]interface{}:
+		var out []string
+		for _, item := range v {
+			out = append(out, w.objects(predicate, item, indent, nested)...)
+		}
+		return out
+	case []map[string]string:
+		var out []string
+		for _, item := range v {
+			out = append(out, w.objects(predicate, item, indent, nested)...)
+		}
+		return out
+	case []string:
+		var out []string
+		for _, s := range v {
+			out = append(out, turtleLiteral(s))
+		}
+		return out
+	case string:
+		if v == "" {
+			return nil
+		}
+		if turtleIRIValues[predicate] {
+			return []string{"<" + v + ">"}
+		}
+		return []string{turtleLiteral(v)}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case int:
+		return []string{strconv.Itoa(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	default:
+		return []string{turtleLiteral(fmt.Sprint(v))}
+	}
+}
+
+// turtlePredicate returns the prefixed name for a JSON-LD key, or "" for
+// keywords and unmapped terms.
+func turtlePredicate(key string) string {
+	if strings.HasPrefix(key, "@") {
+		return ""
+	}
+	if strings.Contains(key, ":") {
+		return key
+	}
+	return turtleTerms[key]
+}
+
+// turtleLiteral quotes s as a Turtle string literal.
+func turtleLiteral(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func turtleSubject(n map[string]interface{}) string {
+	if id, ok := n["@id"].(string); ok && id != "" {
+		return "<" + id + ">"
+	}
+	return "[]"
+}
+
+// jsonLDValue converts the typed documents of this package, such as Catalog,
+// to the generic form the Turtle writer walks: structs become maps keyed by
+// their JSON names, without the empty omitempty fields, and LangMap language
+// maps and Ref references, also in slices, become map[string]string.
+func jsonLDValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonLDValue(v.Elem())
+	case reflect.Struct:
+		if ref, ok := v.Interface().(Ref); ok {
+			return map[string]string{"@id": ref.ID}
+		}
+		node := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			name, opts, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			field := v.Field(i)
+			if name == "" || name == "-" || (opts == "omitempty" && isEmptyValue(field)) {
+				continue
+			}
+			node[name] = jsonLDValue(field)
+		}
+		return node
+	case reflect.Map:
+		if texts, ok := v.Interface().(LangMap); ok {
+			return map[string]string(texts)
+		}
+		if m, ok := v.Interface().(map[string]interface{}); ok {
+			out := make(map[string]interface{}, len(m))
+			for k, item := range m {
+				out[k] = jsonLDValue(reflect.ValueOf(item))
+			}
+			return out
+		}
+	case reflect.Slice:
+		if refs, ok := v.Interface().([]Ref); ok {
+			out := make([]map[string]string, 0, len(refs))
+			for _, ref := range refs {
+				out = append(out, map[string]string{"@id": ref.ID})
+			}
+			return out
+		}
+		if v.Type().Elem().Kind() == reflect.Struct {
+			out := make([]map[string]interface{}, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				out = append(out, jsonLDValue(v.Index(i)).(map[string]interface{}))
+			}
+			return out
+		}
+	}
+	return v.Interface()
+}
+
+// isEmptyValue reports whether v is empty as of the omitempty JSON option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,51 @@
+// © 2024 NOI Techpark <digital@noi.bz.it>
+// SPDX-License-Identifier: AGPL-3.0-or-later
+
+package transformers
+
+// ToVoID describes the DCAT catalog built from datasets as a void:Dataset,
+// including entity and triple counts, the vocabularies in use and dump locations.
+func ToVoID(p Publisher, datasets []Dataset) map[string]interface{} {
+	return map[string]interface{}{
+		"@context": map[string]interface{}{
+			"void": "http://rdfs.org/ns/void#",
+			"dct":  "http://purl.org/dc/terms/",
+			"foaf": "http://xmlns.com/foaf/0.1/",
+		},
+		"@type":           "void:Dataset",
+		"@id":             p.BaseURL + ".well-known/void",
+		"dct:title":       p.CatalogTitle(),
+		"dct:description": p.CatalogDescription(),
+		"dct:publisher": map[string]interface{}{
+			"@type":     "foaf:Organization",
+			"foaf:name": p.Name,
+			"foaf:homepage": map[string]string{
+				"@id": p.URL,
+			},
+		},
+		"void:entities":     len(datasets),
+		"void:triples":      countTriples(ToDCAT(p, datasets, DefaultLanguage)),
+		"void:rootResource": map[string]string{"@id": p.BaseURL + "api-catalog"},
+		"void:vocabulary": []map[string]string{
+			{"@id": "https://www.w3.org/ns/dcat#"},
+			{"@id": "http://purl.org/dc/terms/"},
+			{"@id": "http://xmlns.com/foaf/0.1/"},
+		},
+		"void:dataDump": []map[string]string{
+			{"@id": p.BaseURL + "dcat/dump"},
+		},
+		"void:exampleResource": exampleResources(datasets),
+	}
+}
+
+// exampleResources returns the @id of the first few datasets.
+func exampleResources(datasets []Dataset) []map[string]string {
+	var out []map[string]string
+	for i, ds := range datasets {
+		if i == 3 {
+			break
+		}
+		out = append(out, map[string]string{"@id": ds.Self})
+	}
+	return out
+}